@@ -0,0 +1,95 @@
+package expose
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// defaultBlobContentType is the content-type of [Blob]s, that do not declare one
+const defaultBlobContentType = "application/octet-stream"
+
+// Blob is a binary result, like a PDF or an image. Functions, that return a Blob, e.g. `Func[Req, expose.Blob]`,
+// respond with the content of `Reader` instead of an encoded value, regardless of the "Accept" header.
+// The reader is closed after the response is written, when it is an [io.Closer].
+//
+// The response has a `Content-Length`, when the length of the content is known: when the reader is a [LenReader],
+// like [bytes.Reader], [bytes.Buffer] and [strings.Reader], or a file (see [os.File.Stat]).
+// Otherwise the content is streamed with chunked transfer encoding.
+//
+// The spec documents the response as `format: binary` in the content types declared with [BlobContentType].
+type Blob struct {
+	// ContentType is the content-type of the response. Default: application/octet-stream
+	ContentType string
+	Reader      io.Reader
+}
+
+// LenReader is a reader, that knows the number of its unread bytes
+type LenReader interface {
+	io.Reader
+	Len() int
+}
+
+// BlobContentType documents the content types of the [Blob]s, that the function returns. Default: application/octet-stream
+func BlobContentType(contentTypes ...string) FuncOpt {
+	return func(s *functionSettings) {
+		s.blobContentTypes = append(s.blobContentTypes, contentTypes...)
+	}
+}
+
+// writeBlob streams the content of `blob` to `w`
+func writeBlob(w http.ResponseWriter, blob Blob) error {
+	contentType := blob.ContentType
+	if contentType == "" {
+		contentType = defaultBlobContentType
+	}
+	w.Header().Set("content-type", contentType)
+
+	if blob.Reader == nil {
+		return nil
+	}
+	if closer, ok := blob.Reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if n, ok := blobLength(blob.Reader); ok {
+		w.Header().Set("Content-Length", strconv.FormatInt(n, 10))
+	}
+	_, err := io.Copy(w, blob.Reader)
+	return err
+}
+
+// blobLength returns the length of the content of `r`, when it is known
+func blobLength(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case LenReader:
+		return int64(r.Len()), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		// the file might have been read partially
+		if seeker, ok := r.(io.Seeker); ok {
+			offset, err := seeker.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return 0, false
+			}
+			return info.Size() - offset, true
+		}
+		return info.Size(), true
+	default:
+		return 0, false
+	}
+}
+
+// blobContent describes the response of a function, that returns a [Blob], in its declared content types
+func blobContent(fn Function) openapi3.Content {
+	contentTypes := getFuncSettings(fn).blobContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = []string{defaultBlobContentType}
+	}
+	return openapi3.NewContentWithSchema(openapi3.NewStringSchema().WithFormat("binary"), contentTypes)
+}
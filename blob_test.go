@@ -0,0 +1,102 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestBlob(t *testing.T) {
+	var reader *closeRecorder
+	fns := []Function{
+		Func("/reports/render", func(ctx context.Context, name string) (Blob, error) {
+			reader = &closeRecorder{Reader: strings.NewReader("%PDF " + name)}
+			return Blob{ContentType: "application/pdf", Reader: reader}, nil
+		}, BlobContentType("application/pdf")),
+		FuncNullary("/reports/raw", func(ctx context.Context) (Blob, error) {
+			return Blob{Reader: strings.NewReader("raw")}, nil
+		}),
+	}
+	h, err := NewHandler(fns, WithResponseValidation(true))
+	got.T(t).Must().Nil(err)
+
+	t.Run("streams the content", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/reports/render", strings.NewReader(`"q1"`))
+		r.Header.Set("content-type", "application/json")
+		r.Header.Set("accept", "application/pdf")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/pdf")
+		g.Eq(w.Body.String(), "%PDF q1")
+		g.True(reader.closed)
+		// the length of the wrapped reader is unknown
+		g.Eq(w.Header().Get("content-length"), "")
+	})
+
+	t.Run("default content type", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reports/raw", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/octet-stream")
+		g.Eq(w.Body.String(), "raw")
+		g.Eq(w.Header().Get("content-length"), "3")
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		content := spec.Paths.Find("/reports/render").Post.Responses.Status(200).Value.Content
+		g.Nil(content.Get("application/json"))
+		g.Eq(content.Get("application/pdf").Schema.Value.Format, "binary")
+
+		content = spec.Paths.Find("/reports/raw").Post.Responses.Status(200).Value.Content
+		g.Eq(content.Get("application/octet-stream").Schema.Value.Format, "binary")
+	})
+}
+
+func TestBlobLength(t *testing.T) {
+	g := got.T(t)
+
+	n, ok := blobLength(bytes.NewBufferString("abc"))
+	g.True(ok)
+	g.Eq(n, int64(3))
+
+	_, ok = blobLength(io.MultiReader(strings.NewReader("abc")))
+	g.False(ok)
+
+	file, err := os.CreateTemp(t.TempDir(), "blob")
+	g.Must().Nil(err)
+	defer file.Close()
+	_, err = file.WriteString("hello world")
+	g.Must().Nil(err)
+	_, err = file.Seek(6, io.SeekStart)
+	g.Must().Nil(err)
+
+	n, ok = blobLength(file)
+	g.True(ok)
+	g.Eq(n, int64(5))
+}
@@ -0,0 +1,179 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a [CircuitBreaker]
+type CircuitState int
+
+const (
+	// CircuitClosed lets all calls pass
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects all calls until the reset timeout has passed
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial call pass. Its result decides whether the circuit is closed or opened again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprint("CircuitState(", int(s), ")")
+	}
+}
+
+// CircuitBreakerOptions configures a [CircuitBreaker]
+type CircuitBreakerOptions struct {
+	// FailureThreshold is the number of consecutive failures, that open the circuit. Default: 5
+	FailureThreshold int
+	// ResetTimeout is the duration the circuit stays open, before a trial call is let through. Default: 30s
+	ResetTimeout time.Duration
+}
+
+// CircuitBreaker guards the calls of exposed functions. See [WithCircuitBreaker].
+// Errors that are [ErrApplication]s, canceled calls (see [ErrCanceled]) and calls, that exceeded the timeout set by the client
+// (see [WithHeaderTimeout]), do not count as failures.
+// Calls, that panic, count as failures.
+type CircuitBreaker struct {
+	opts CircuitBreakerOptions
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker creates a closed [CircuitBreaker]
+func NewCircuitBreaker(opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.ResetTimeout <= 0 {
+		opts.ResetTimeout = 30 * time.Second
+	}
+	return &CircuitBreaker{opts: opts, now: time.Now}
+}
+
+// State returns the current state of the circuit
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.opts.ResetTimeout {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// Failures returns the number of consecutive failures
+func (cb *CircuitBreaker) Failures() int {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.failures
+}
+
+// ErrCircuitOpen is returned by functions, whose [CircuitBreaker] rejected the call.
+// The handler responds with 503 Service Unavailable and a `Retry-After` header. See [CircuitOpenError].
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitOpenError is returned, when a [CircuitBreaker] rejects a call.
+// Its status is 503 Service Unavailable and it is [Retryable] after the remaining time until the circuit lets a trial call pass.
+type CircuitOpenError struct {
+	retryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrCircuitOpen, e.retryAfter)
+}
+
+func (e *CircuitOpenError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e *CircuitOpenError) Status() int {
+	return http.StatusServiceUnavailable
+}
+
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// errCallPanicked is recorded for calls, that panicked
+var errCallPanicked = errors.New("the call panicked")
+
+// call runs `fn` when the circuit permits it and records the result
+func (cb *CircuitBreaker) call(ctx context.Context, fn func(ctx context.Context) (res any, err error)) (res any, err error) {
+	if err := cb.allow(); err != nil {
+		return nil, err
+	}
+
+	// a panic counts as failure, so that the trial call of a half-open circuit is released
+	err = errCallPanicked
+	defer func() { cb.record(ctx, err) }()
+
+	return fn(ctx)
+}
+
+func (cb *CircuitBreaker) allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		elapsed := cb.now().Sub(cb.openedAt)
+		if elapsed < cb.opts.ResetTimeout {
+			return &CircuitOpenError{retryAfter: cb.opts.ResetTimeout - elapsed}
+		}
+		cb.state = CircuitHalfOpen
+		cb.trial = true
+		return nil
+	case CircuitHalfOpen:
+		if cb.trial {
+			return &CircuitOpenError{retryAfter: time.Second}
+		}
+		cb.trial = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (cb *CircuitBreaker) record(ctx context.Context, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.trial = false
+
+	// the call was canceled by the client or exceeded the client's deadline,
+	// so it neither proves nor disproves the health of the function
+	if errors.Is(err, context.Canceled) || clientDeadlineExceeded(ctx) {
+		return
+	}
+
+	if err == nil || errors.Is(err, ErrApplication) {
+		cb.failures = 0
+		cb.state = CircuitClosed
+		return
+	}
+
+	cb.failures++
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.opts.FailureThreshold {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.now()
+	}
+}
@@ -0,0 +1,108 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	g := got.T(t)
+
+	now := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 3, ResetTimeout: 10 * time.Second})
+	cb.now = func() time.Time { return now }
+
+	fail := true
+	h, err := NewHandler([]Function{
+		FuncNullaryVoid("/downstream/call", func(ctx context.Context) error {
+			if fail {
+				return errors.New("downstream unavailable")
+			}
+			return nil
+		}, WithCircuitBreaker(cb)),
+	})
+	g.Must().Nil(err)
+
+	call := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/downstream/call", nil))
+		return w
+	}
+
+	for i := 0; i < 3; i++ {
+		g.Eq(call().Code, http.StatusInternalServerError)
+	}
+	g.Eq(cb.State(), CircuitOpen)
+	g.Eq(cb.Failures(), 3)
+
+	w := call()
+	g.Eq(w.Code, http.StatusServiceUnavailable)
+	g.Eq(w.Header().Get("Retry-After"), "10")
+
+	now = now.Add(10 * time.Second)
+	g.Eq(cb.State(), CircuitHalfOpen)
+
+	fail = false
+	g.Eq(call().Code, http.StatusOK)
+	g.Eq(cb.State(), CircuitClosed)
+	g.Eq(cb.Failures(), 0)
+}
+
+func TestCircuitBreakerHalfOpenFailure(t *testing.T) {
+	g := got.T(t)
+
+	now := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, ResetTimeout: time.Second})
+	cb.now = func() time.Time { return now }
+
+	failing := func(ctx context.Context) (any, error) { return nil, errors.New("fail") }
+
+	_, err := cb.call(context.Background(), failing)
+	g.Eq(errors.Is(err, ErrCircuitOpen), false)
+	g.Eq(cb.State(), CircuitOpen)
+
+	now = now.Add(time.Second)
+	_, err = cb.call(context.Background(), failing)
+	g.Eq(errors.Is(err, ErrCircuitOpen), false)
+	g.Eq(cb.State(), CircuitOpen)
+
+	_, err = cb.call(context.Background(), failing)
+	g.Is(err, ErrCircuitOpen)
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	g := got.T(t)
+
+	now := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1, ResetTimeout: time.Second})
+	cb.now = func() time.Time { return now }
+
+	panicking := func(ctx context.Context) (any, error) { panic("boom") }
+	call := func(fn func(ctx context.Context) (any, error)) (err error) {
+		defer func() {
+			if recover() != nil {
+				err = errCallPanicked
+			}
+		}()
+		_, err = cb.call(context.Background(), fn)
+		return err
+	}
+
+	g.Eq(call(panicking), errCallPanicked)
+	g.Eq(cb.State(), CircuitOpen)
+
+	// the panicking trial call opens the circuit again, instead of blocking the later trials
+	now = now.Add(time.Second)
+	g.Eq(call(panicking), errCallPanicked)
+	g.Eq(cb.State(), CircuitOpen)
+
+	now = now.Add(time.Second)
+	g.Nil(call(func(ctx context.Context) (any, error) { return nil, nil }))
+	g.Eq(cb.State(), CircuitClosed)
+}
@@ -0,0 +1,153 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// CachedResponse is a response recorded by the response cache, see [Cache]
+type CachedResponse struct {
+	Header http.Header
+	Body   []byte
+	// ETag is the quoted entity tag of the body
+	ETag string
+}
+
+// CacheStore stores the responses of cached functions (see [Cache]) under the hash of the request
+type CacheStore interface {
+	// Get returns the response cached under `key`. `ok` is false, when there is none or it is expired.
+	Get(ctx context.Context, key string) (res CachedResponse, ok bool, err error)
+	// Set caches the response under `key` for `ttl`
+	Set(ctx context.Context, key string, res CachedResponse, ttl time.Duration) error
+}
+
+// WithCacheStore stores the responses of cached functions (see [Cache]) in `store`, e.g. to share them between instances.
+// Default: a [MemoryCacheStore]
+func WithCacheStore(store CacheStore) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.cacheStore = store
+	}
+}
+
+// Cache opts the function into the response cache of the handler (see [WithCacheStore]). Successful responses are cached for `ttl`,
+// keyed by the path, the serialized request (the method, the query, the body and its content type), the `Accept` header and the caller:
+// the principal of the authentication (see [WithPrincipal]) and the credentials (the `Authorization` and `Cookie` headers).
+// Requests are authenticated (see [WithAuth]) before the cache is read. When the credentials are passed otherwise, e.g. in an
+// `X-Api-Key` header, the [AuthFunc] must set the principal, so that cached responses are never served to other callers.
+//
+// Responses carry an `ETag` and requests with a matching `If-None-Match` header are answered with 304 Not Modified.
+// Void results and errors are not cached. Only cache functions, whose results do not depend on anything but the request.
+func Cache(ttl time.Duration) FuncOpt {
+	return func(s *functionSettings) {
+		s.cacheTTL = ttl
+	}
+}
+
+// cached serves the cached responses of `fn` or caches the responses of `next`, when `fn` is cached (see [Cache])
+func (settings *handlerSettings) cached(fn Function, next http.HandlerFunc) http.HandlerFunc {
+	ttl := getFuncSettings(fn).cacheTTL
+	if ttl <= 0 || isVoid(fn.Res()) {
+		return next
+	}
+	store := settings.cacheStore
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !slices.Contains(functionMethods(fn), r.Method) {
+			next(w, r)
+			return
+		}
+
+		data, err := io.ReadAll(settings.limitBody(w, r, fn))
+		if err != nil {
+			settings.writeError(ctx, w, nil, SetErrStatus(fmt.Errorf("%w: %w", ErrDecode, err), http.StatusBadRequest))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		key := cacheKey(fn, r, data)
+
+		cached, ok, err := store.Get(ctx, key)
+		if err != nil {
+			settings.writeError(ctx, w, nil, fmt.Errorf("failed to read the cached response: %w", err))
+			return
+		}
+		if !ok {
+			rec := newResponseRecorder(nil)
+			next(rec, r)
+			if rec.status != http.StatusOK || rec.body.Len() == 0 {
+				rec.writeTo(w)
+				return
+			}
+			cached = CachedResponse{Header: rec.header, Body: rec.body.Bytes(), ETag: bodyETag(rec.body.Bytes())}
+			// a failure only causes the next request to call the function again
+			_ = store.Set(ctx, key, cached, ttl)
+		}
+
+		for name, values := range cached.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("ETag", cached.ETag)
+		if etagMatches(r.Header.Get("If-None-Match"), cached.ETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write(cached.Body)
+	}
+}
+
+// cacheKey hashes everything, that the cached response of `fn` depends on
+func cacheKey(fn Function, r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{fn.Path(), r.Method, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Header.Get("Accept"), callerKey(r)} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// callerKey hashes the identity of the caller of the authenticated request `r`:
+// its principal (see [WithPrincipal]) and its credentials, the `Authorization` and `Cookie` headers
+func callerKey(r *http.Request) string {
+	h := sha256.New()
+	for _, part := range []string{Principal(r.Context()), r.Header.Get("Authorization"), r.Header.Get("Cookie")} {
+		io.WriteString(h, part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryCacheStore is a [CacheStore], that keeps the responses in memory.
+// It holds up to 10000 responses (see [MemoryCacheStore.WithCapacity]) and evicts the least recently used ones.
+// It is suited for single instance deployments and tests.
+type MemoryCacheStore struct {
+	responses *ttlMap[string, CachedResponse]
+}
+
+// NewMemoryCacheStore creates an empty [MemoryCacheStore]
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{responses: newTTLMap[string, CachedResponse](defaultMemoryStoreCapacity)}
+}
+
+// WithCapacity limits the store to `n` responses, the least recently used responses are evicted first. 0 is unlimited.
+func (s *MemoryCacheStore) WithCapacity(n int) *MemoryCacheStore {
+	s.responses.setCapacity(n)
+	return s
+}
+
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) (CachedResponse, bool, error) {
+	res, ok := s.responses.get(key)
+	return res, ok, nil
+}
+
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, res CachedResponse, ttl time.Duration) error {
+	s.responses.set(key, res, ttl)
+	return nil
+}
@@ -0,0 +1,153 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	fns := []Function{
+		Func("/square", func(ctx context.Context, n int) (int, error) {
+			calls++
+			if n < 0 {
+				return 0, errors.New("negative")
+			}
+			return n * n, nil
+		}, Cache(time.Minute)),
+		FuncVoid("/noop", func(ctx context.Context, n int) error {
+			calls++
+			return nil
+		}, Cache(time.Minute)),
+	}
+	store := NewMemoryCacheStore()
+	now := time.Now()
+	store.responses.now = func() time.Time { return now }
+	h, err := NewHandler(fns, WithCacheStore(store))
+	got.T(t).Must().Nil(err)
+
+	call := func(path, body string, header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("content-type", "application/json")
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("serves the cached response", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+		first := call("/square", "3", nil)
+		g.Eq(first.Code, http.StatusOK)
+		g.Eq(first.Body.String(), "9\n")
+		g.NotZero(first.Header().Get("ETag"))
+
+		second := call("/square", "3", nil)
+		g.Eq(second.Body.String(), "9\n")
+		g.Eq(second.Header().Get("ETag"), first.Header().Get("ETag"))
+		g.Eq(second.Header().Get("content-type"), "application/json")
+		g.Eq(calls, 1)
+
+		// other requests and callers are cached separately
+		call("/square", "4", nil)
+		call("/square", "3", http.Header{"Authorization": {"Bearer other"}})
+		g.Eq(calls, 3)
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		g := got.T(t)
+		etag := call("/square", "5", nil).Header().Get("ETag")
+
+		w := call("/square", "5", http.Header{"If-None-Match": {etag}})
+		g.Eq(w.Code, http.StatusNotModified)
+		g.Eq(w.Body.Len(), 0)
+
+		w = call("/square", "5", http.Header{"If-None-Match": {`"stale"`}})
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "25\n")
+	})
+
+	t.Run("expires", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+		call("/square", "6", nil)
+		now = now.Add(time.Minute)
+		call("/square", "6", nil)
+		g.Eq(calls, 2)
+	})
+
+	t.Run("errors and void results are not cached", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+		g.Eq(call("/square", "-1", nil).Code, http.StatusInternalServerError)
+		call("/square", "-1", nil)
+		call("/noop", "1", nil)
+		w := call("/noop", "1", nil)
+		g.Eq(w.Header().Get("ETag"), "")
+		g.Eq(calls, 4)
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		op := spec.Paths.Find("/square").Post
+		g.NotNil(op.Parameters.GetByInAndName("header", "If-None-Match"))
+		g.NotNil(op.Responses.Status(http.StatusNotModified))
+		g.NotNil(op.Responses.Status(http.StatusOK).Value.Headers["ETag"])
+
+		g.Nil(spec.Paths.Find("/noop").Post.Responses.Status(http.StatusNotModified))
+	})
+}
+
+func TestCacheAuth(t *testing.T) {
+	calls := 0
+	fns := []Function{
+		FuncNullary("/secret", func(ctx context.Context) (string, error) {
+			calls++
+			return "top secret of " + Principal(ctx), nil
+		}, Cache(time.Minute)),
+	}
+	h, err := NewHandler(fns, WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+		key := r.Header.Get("X-Api-Key")
+		if key == "" {
+			return nil, errors.New("missing api key")
+		}
+		return WithPrincipal(ctx, key), nil
+	}))
+	got.T(t).Must().Nil(err)
+
+	call := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/secret", nil)
+		if apiKey != "" {
+			r.Header.Set("X-Api-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	g := got.T(t)
+	g.Eq(call("alice").Body.String(), "\"top secret of alice\"\n")
+
+	// cache hits are authenticated
+	w := call("")
+	g.Eq(w.Code, http.StatusUnauthorized)
+	g.Eq(w.Header().Get("ETag"), "")
+
+	// the cache is scoped to the principal
+	g.Eq(call("bob").Body.String(), "\"top secret of bob\"\n")
+	g.Eq(call("alice").Body.String(), "\"top secret of alice\"\n")
+	g.Eq(calls, 2)
+}
@@ -0,0 +1,176 @@
+package expose
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Call calls the function at `path` of the handler `h` in-memory, by issuing a JSON encoded POST request.
+// It is meant to simplify integration tests of a [Handler]. When `h` is a [*Handler], the request and the response
+// are encoded in its wire format (see [WithFieldNames] and [WithInt64AsString]) and functions, that do not answer POST
+// requests, are called with their first method (see [Methods]). The requests of GET calls are encoded as query.
+//
+// When the handler responds with an error, the returned error reconstructs the error response:
+// it is a [*ResponseError], carries the error code (see [GetErrCode]) and retry information (see [GetErrRetryable])
+// and is an [ErrApplication], when the status is the status of application errors (see [WithDefaultErrorStatus]).
+func Call[TReq any, TRes any](h http.Handler, path string, req TReq) (TRes, error) {
+	var res TRes
+
+	enc := JsonEncoding
+	method := http.MethodPost
+	appErrorStatus := http.StatusUnprocessableEntity
+	if handler, ok := h.(*Handler); ok {
+		if handler.fieldNames != nil {
+			enc = withFieldNames(JsonEncoding, handler.fieldNames)
+		}
+		if fn, ok := handler.byPath[path]; ok {
+			if methods := functionMethods(fn); !slices.Contains(methods, http.MethodPost) {
+				method = methods[0]
+			}
+		}
+		appErrorStatus = handler.appErrorStatus
+	}
+
+	var body io.Reader = http.NoBody
+	if method == http.MethodGet {
+		query, err := callQuery(req)
+		if err != nil {
+			return res, fmt.Errorf("failed to encode request: %w", err)
+		}
+		path += query
+	} else if !isVoid(req) {
+		var buf bytes.Buffer
+		if err := enc.GetEncoder(&buf).Encode(req); err != nil {
+			return res, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = &buf
+	}
+
+	r, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return res, err
+	}
+	r.Header.Set("content-type", JsonEncoding.MimeType)
+	r.Header.Set("accept", JsonEncoding.MimeType)
+
+	w := newResponseRecorder(nil)
+	h.ServeHTTP(w, r)
+
+	if w.status >= 400 {
+		err := ErrorFromResponse(&http.Response{StatusCode: w.status, Header: w.header, Body: io.NopCloser(&w.body)})
+		var resErr *ResponseError
+		if errors.As(err, &resErr) {
+			resErr.appErrorStatus = appErrorStatus
+		}
+		return res, err
+	}
+
+	if isVoid(res) {
+		return res, nil
+	}
+
+	if err := enc.GetDecoder(&w.body).Decode(&res); err != nil {
+		return res, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return res, nil
+}
+
+// callQuery encodes the request `req` of a GET call as query, e.g. '?name=alice&tags=a&tags=b'.
+// The fields are named by their `json` tags, like the query is decoded (see [Methods]).
+func callQuery(req any) (string, error) {
+	if isVoid(req) {
+		return "", nil
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return "", fmt.Errorf("GET requests are encoded as query, but the request %T is not a struct", req)
+	}
+
+	query := url.Values{}
+	for name, value := range fields {
+		switch value := value.(type) {
+		case nil:
+		case []any:
+			for _, v := range value {
+				query.Add(name, fmt.Sprint(v))
+			}
+		default:
+			query.Set(name, fmt.Sprint(value))
+		}
+	}
+	if len(query) == 0 {
+		return "", nil
+	}
+	return "?" + query.Encode(), nil
+}
+
+// ResponseError is returned by [Call], when the handler responds with an error status
+type ResponseError struct {
+	// StatusCode is the status of the error response
+	StatusCode int
+	// Body is the decoded error response. It is nil, when the error was not encoded as JSON.
+	Body    map[string]any
+	message string
+	// appErrorStatus is the status of application errors, see [WithDefaultErrorStatus]
+	appErrorStatus int
+}
+
+func (e *ResponseError) Error() string {
+	return e.message
+}
+
+func (e *ResponseError) Status() int {
+	return e.StatusCode
+}
+
+func (e *ResponseError) Is(target error) bool {
+	appErrorStatus := e.appErrorStatus
+	if appErrorStatus == 0 {
+		appErrorStatus = http.StatusUnprocessableEntity
+	}
+	return target == ErrApplication && e.StatusCode == appErrorStatus
+}
+
+// ErrorFromResponse reconstructs the error of an error response of a [Handler]. See [Call] for the details.
+// It is used by generated clients (see [GenerateGoClient]).
+func ErrorFromResponse(res *http.Response) error {
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read error response: %w", err)
+	}
+
+	resErr := &ResponseError{StatusCode: res.StatusCode, message: string(data)}
+
+	if err := json.Unmarshal(data, &resErr.Body); err != nil {
+		resErr.Body = nil
+		return resErr
+	}
+	if msg, ok := resErr.Body["message"].(string); ok {
+		resErr.message = msg
+	}
+
+	err = resErr
+	if code, ok := resErr.Body["code"].(string); ok {
+		err = SetErrCode(err, code)
+	}
+	if retryable, _ := resErr.Body["retryable"].(bool); retryable {
+		seconds, _ := strconv.Atoi(res.Header.Get("Retry-After"))
+		err = SetErrRetryable(err, time.Duration(seconds)*time.Second)
+	}
+
+	return err
+}
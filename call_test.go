@@ -0,0 +1,158 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestCall(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/users/greet", func(ctx context.Context, u user) (string, error) {
+			return "hello " + u.Name, nil
+		}),
+		FuncNullary("/users/me", func(ctx context.Context) (user, error) {
+			return user{Name: "alice"}, nil
+		}),
+		FuncVoid("/users/save", func(ctx context.Context, u user) error {
+			return SetErrCode(fmt.Errorf("%w: name taken", ErrApplication), "conflict")
+		}),
+		FuncNullaryVoid("/users/sync", func(ctx context.Context) error {
+			return SetErrRetryable(errors.New("unavailable"), 3*time.Second)
+		}),
+	})
+	got.T(t).Must().Nil(err)
+
+	t.Run("result", func(t *testing.T) {
+		g := got.T(t)
+
+		greeting, err := Call[user, string](h, "/users/greet", user{Name: "bob"})
+		g.Must().Nil(err)
+		g.Eq(greeting, "hello bob")
+
+		me, err := Call[Void, user](h, "/users/me", Void{})
+		g.Must().Nil(err)
+		g.Eq(me, user{Name: "alice"})
+	})
+
+	t.Run("application error", func(t *testing.T) {
+		g := got.T(t)
+
+		_, err := Call[user, Void](h, "/users/save", user{Name: "bob"})
+		g.Is(err, ErrApplication)
+		g.Eq(err.Error(), "application error: name taken")
+
+		code, ok := GetErrCode(err)
+		g.True(ok)
+		g.Eq(code, "conflict")
+
+		var resErr *ResponseError
+		g.True(errors.As(err, &resErr))
+		g.Eq(resErr.StatusCode, http.StatusUnprocessableEntity)
+	})
+
+	t.Run("retryable error", func(t *testing.T) {
+		g := got.T(t)
+
+		_, err := Call[Void, Void](h, "/users/sync", Void{})
+		g.Eq(errors.Is(err, ErrApplication), false)
+
+		retryAfter, ok := GetErrRetryable(err)
+		g.True(ok)
+		g.Eq(retryAfter, 3*time.Second)
+
+		status, ok := GetErrStatus(err)
+		g.True(ok)
+		g.Eq(status, http.StatusInternalServerError)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		g := got.T(t)
+
+		_, err := Call[Void, Void](h, "/users/unknown", Void{})
+
+		status, _ := GetErrStatus(err)
+		g.Eq(status, http.StatusNotFound)
+	})
+}
+
+func TestCallWireFormat(t *testing.T) {
+	type idReq struct {
+		UserName string
+	}
+	type idRes struct {
+		ID       int64
+		UserName string
+	}
+
+	opts := []HandlerOption{WithFieldNames(CamelCase), WithInt64AsString(true)}
+	h, err := NewHandler([]Function{
+		Func("/users/id", func(ctx context.Context, req idReq) (idRes, error) {
+			return idRes{ID: 9007199254740993, UserName: req.UserName}, nil
+		}),
+	}, opts...)
+	got.T(t).Must().Nil(err)
+
+	t.Run("call", func(t *testing.T) {
+		g := got.T(t)
+		res, err := Call[idReq, idRes](h, "/users/id", idReq{UserName: "alice"})
+		g.Must().Nil(err)
+		g.Eq(res, idRes{ID: 9007199254740993, UserName: "alice"})
+	})
+
+	t.Run("wire encoding", func(t *testing.T) {
+		g := got.T(t)
+		var buf bytes.Buffer
+		g.Must().Nil(WireEncoding(opts...).GetEncoder(&buf).Encode(idRes{ID: 1, UserName: "bob"}))
+		g.Eq(strings.TrimSpace(buf.String()), `{"id":"1","userName":"bob"}`)
+
+		var res idRes
+		g.Must().Nil(WireEncoding(opts...).GetDecoder(&buf).Decode(&res))
+		g.Eq(res, idRes{ID: 1, UserName: "bob"})
+	})
+}
+
+func TestCallMethods(t *testing.T) {
+	type search struct {
+		Query string   `json:"q"`
+		Tags  []string `json:"tags"`
+		Limit int      `json:"limit"`
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/users/search", func(ctx context.Context, s search) (search, error) {
+			return s, nil
+		}, Methods(http.MethodGet)),
+		FuncNullaryVoid("/users/fail", func(ctx context.Context) error {
+			return fmt.Errorf("%w: invalid", ErrApplication)
+		}),
+	}, WithDefaultErrorStatus(http.StatusBadRequest, http.StatusInternalServerError))
+	got.T(t).Must().Nil(err)
+
+	t.Run("GET", func(t *testing.T) {
+		g := got.T(t)
+		res, err := Call[search, search](h, "/users/search", search{Query: "al", Tags: []string{"a", "b"}, Limit: 10})
+		g.Must().Nil(err)
+		g.Eq(res, search{Query: "al", Tags: []string{"a", "b"}, Limit: 10})
+	})
+
+	t.Run("application error status", func(t *testing.T) {
+		g := got.T(t)
+		_, err := Call[Void, Void](h, "/users/fail", Void{})
+		g.Is(err, ErrApplication)
+
+		status, _ := GetErrStatus(err)
+		g.Eq(status, http.StatusBadRequest)
+	})
+}
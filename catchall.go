@@ -0,0 +1,197 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// FuncCatchAll creates a [Function], that handles all requests below `prefix`, that no other function is exposed at,
+// e.g. to forward them to another service. `fn` receives the remaining path, e.g. '/users/get' for a request of '/legacy/users/get'
+// with the prefix '/legacy', and the raw body of the request. The result is written as the raw body of the response.
+//
+// The catch-all is not documented in the spec. Like other functions, it is called through the authentication (see [WithAuth]),
+// the interceptors (see [WithInterceptor]), the timeouts and the logger, and its errors are encoded with the default encoding.
+// Functions below the prefix take precedence, since their paths are more specific. The catch-all with the prefix '/' replaces
+// the not found handler (see [WithNotFoundHandler]), except for the index at '/' (see [WithIndexPage]).
+// Catch-alls are neither listed by [Handler.Functions] nor called by [Handler.Invoke], since they have no path of their own.
+// [MaxBodyBytes] is the only option, that applies to a catch-all. [NewHandler] rejects the other options, e.g. [Cache] or [Methods].
+func FuncCatchAll(prefix string, fn func(ctx context.Context, path string, body []byte) ([]byte, error), opts ...FuncOpt) Function {
+	prefix = "/" + strings.Trim(prefix, "/")
+	return &catchAllFunction{
+		prefix:   prefix,
+		fn:       fn,
+		settings: newSettings(opts...),
+	}
+}
+
+// catchAllFunction is the [Function] created by [FuncCatchAll]
+type catchAllFunction struct {
+	prefix   string
+	fn       func(ctx context.Context, path string, body []byte) ([]byte, error)
+	settings functionSettings
+}
+
+// catchAllRequest is the request, that [catchAllFunction.Apply] decodes. The handler does not call Apply, it serves the
+// catch-alls with serveCatchAll, and [Handler.Invoke] does not find them.
+type catchAllRequest struct {
+	Path string `json:"path"`
+	Body []byte `json:"body"`
+}
+
+func (c *catchAllFunction) Name() string {
+	return "catchAll"
+}
+
+func (c *catchAllFunction) Module() string {
+	return moduleOf(c.pattern())
+}
+
+func (c *catchAllFunction) Path() string {
+	return c.prefix
+}
+
+func (c *catchAllFunction) Req() any {
+	return catchAllRequest{}
+}
+
+func (c *catchAllFunction) Res() any {
+	return []byte{}
+}
+
+func (c *catchAllFunction) funcSettings() functionSettings {
+	return c.settings
+}
+
+func (c *catchAllFunction) Apply(ctx context.Context, dec Decoder, spec openapi3.T) (any, error) {
+	var req catchAllRequest
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, SetErrStatus(fmt.Errorf("%w: %w", ErrDecode, err), http.StatusBadRequest)
+	}
+	return c.fn(ctx, req.Path, req.Body)
+}
+
+// pattern is the pattern of the mux, that matches all paths below the prefix
+func (c *catchAllFunction) pattern() string {
+	return strings.TrimSuffix(c.prefix, "/") + "/"
+}
+
+func isCatchAll(fn Function) bool {
+	_, ok := fn.(*catchAllFunction)
+	return ok
+}
+
+// splitCatchAlls separates the catch-all functions (see [FuncCatchAll]) from the other functions `fns`
+func splitCatchAlls(fns []Function) (exposed []Function, catchAlls []*catchAllFunction, err error) {
+	prefixes := map[string]bool{}
+	for _, fn := range fns {
+		catchAll, ok := fn.(*catchAllFunction)
+		if !ok {
+			exposed = append(exposed, fn)
+			continue
+		}
+		if prefixes[catchAll.prefix] {
+			return nil, nil, fmt.Errorf("duplicate catch-all function at '%s'", catchAll.prefix)
+		}
+		if opt := ineffectiveCatchAllOption(catchAll.settings); opt != "" {
+			return nil, nil, fmt.Errorf("the catch-all function at '%s' does not support the option %s", catchAll.prefix, opt)
+		}
+		prefixes[catchAll.prefix] = true
+		catchAlls = append(catchAlls, catchAll)
+	}
+	return exposed, catchAlls, nil
+}
+
+// ineffectiveCatchAllOption returns the name of an option of the catch-all settings `s`, that has no effect,
+// since catch-alls are neither documented nor decoded
+func ineffectiveCatchAllOption(s functionSettings) string {
+	switch {
+	case s.validate:
+		return "Validate"
+	case len(s.aliases) > 0:
+		return "Aliases"
+	case len(s.security) > 0:
+		return "RequireSecurity"
+	case s.breaker != nil:
+		return "WithCircuitBreaker"
+	case len(s.errorResponses) > 0:
+		return "ErrorResponse"
+	case len(s.tags) > 0:
+		return "WithTags"
+	case len(s.headers) > 0:
+		return "ResponseHeader"
+	case s.idempotent:
+		return "Idempotent"
+	case len(s.blobContentTypes) > 0:
+		return "BlobContentType"
+	case s.protoBody:
+		return "ProtoBody"
+	case len(s.extensions) > 0:
+		return "WithExtension"
+	case s.requestDefaults != nil:
+		return "RequestDefaults"
+	case s.cacheTTL != 0:
+		return "Cache"
+	case len(s.methods) > 0:
+		return "Methods"
+	case len(s.examples) > 0:
+		return "WithExamples"
+	}
+	return ""
+}
+
+// serveCatchAll calls the catch-all `fn` with the path of the request below its prefix and the body of the request
+func (settings *handlerSettings) serveCatchAll(fn *catchAllFunction) http.HandlerFunc {
+	enc := settings.encoding[settings.defaultEncoding]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(settings.assignRequestID(r.Context(), w, r))
+		ctx, span := settings.startSpan(r, fn)
+		defer span.End()
+
+		var ok bool
+		if ctx, ok = settings.authenticate(ctx, w, r, span, &enc); !ok {
+			return
+		}
+
+		start := time.Now()
+		data, err := io.ReadAll(settings.limitBody(w, r, fn))
+		if err != nil {
+			settings.writeError(ctx, w, &enc, SetErrStatus(fmt.Errorf("%w: %w", ErrDecode, err), http.StatusBadRequest))
+			return
+		}
+		p := "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, fn.prefix), "/")
+
+		applyCtx, cancel := settings.withTimeout(ctx, r)
+		defer cancel()
+		res, err := settings.call(applyCtx, fn, func(ctx context.Context) (any, error) {
+			return fn.fn(ctx, p, data)
+		})
+
+		if settings.logger != nil {
+			settings.logCall(ctx, fn, start, int64(len(data)), err)
+		}
+		if err != nil {
+			failSpan(span, err)
+			settings.writeError(ctx, w, &enc, err)
+			return
+		}
+		out, _ := res.([]byte)
+		w.Write(out)
+	}
+}
+
+// withoutCatchAlls removes the catch-all functions, which are not documented in the spec
+func withoutCatchAlls(fns []Function) []Function {
+	if !slices.ContainsFunc(fns, isCatchAll) {
+		return fns
+	}
+	return slices.DeleteFunc(slices.Clone(fns), isCatchAll)
+}
@@ -0,0 +1,171 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestFuncCatchAll(t *testing.T) {
+	var calls []string
+	fns := []Function{
+		FuncNullary("/legacy/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}),
+		FuncCatchAll("/legacy/", func(ctx context.Context, path string, body []byte) ([]byte, error) {
+			calls = append(calls, path)
+			if path == "/fail" {
+				return nil, SetErrStatus(errors.New("upstream failed"), http.StatusBadGateway)
+			}
+			return append([]byte(path+":"), body...), nil
+		}),
+	}
+	h, err := NewHandler(fns, WithPathPrefix("/rpc"))
+	got.T(t).Must().Nil(err)
+
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("remaining path", func(t *testing.T) {
+		g := got.T(t)
+		w := serve("/rpc/legacy/users/get", "raw")
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "/users/get:raw")
+	})
+
+	t.Run("exposed functions take precedence", func(t *testing.T) {
+		g := got.T(t)
+		calls = nil
+		w := serve("/rpc/legacy/ping", "")
+		g.Eq(w.Code, http.StatusOK)
+		g.Has(w.Body.String(), "pong")
+		g.Len(calls, 0)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		g := got.T(t)
+		w := serve("/rpc/legacy/fail", "")
+		g.Eq(w.Code, http.StatusBadGateway)
+		g.Has(w.Body.String(), `"message":"upstream failed"`)
+	})
+
+	t.Run("other paths are not found", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(serve("/rpc/other", "").Code, http.StatusNotFound)
+	})
+
+	t.Run("not documented", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		g.Eq(spec.Paths.Len(), 1)
+		g.Len(h.Functions(), 1)
+
+		_, err = h.Invoke(context.Background(), "/legacy/", catchAllRequest{Path: "/users/get"})
+		var notFound *NotFoundError
+		g.True(errors.As(err, &notFound))
+	})
+
+	t.Run("root", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler([]Function{
+			FuncCatchAll("/", func(ctx context.Context, path string, body []byte) ([]byte, error) {
+				return []byte(path), nil
+			}),
+		}, WithIndexPage("/"))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anything/else", nil))
+		g.Eq(w.Body.String(), "/anything/else")
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Neq(w.Body.String(), "/")
+	})
+
+	t.Run("duplicate prefix", func(t *testing.T) {
+		g := got.T(t)
+		catchAll := func(ctx context.Context, path string, body []byte) ([]byte, error) {
+			return nil, nil
+		}
+		_, err := NewHandler([]Function{FuncCatchAll("/a", catchAll), FuncCatchAll("/a/", catchAll)})
+		g.Has(err.Error(), "duplicate catch-all function at '/a'")
+	})
+
+	t.Run("ineffective options", func(t *testing.T) {
+		g := got.T(t)
+		catchAll := func(ctx context.Context, path string, body []byte) ([]byte, error) {
+			return nil, nil
+		}
+		for name, opt := range map[string]FuncOpt{
+			"WithCircuitBreaker": WithCircuitBreaker(NewCircuitBreaker(CircuitBreakerOptions{})),
+			"Validate":           Validate(true),
+			"Cache":              Cache(time.Minute),
+			"Idempotent":         Idempotent(),
+			"Methods":            Methods(http.MethodGet),
+			"ResponseHeader":     ResponseHeader("X-Total", "the total", openapi3.NewIntegerSchema()),
+		} {
+			_, err := NewHandler([]Function{FuncCatchAll("/a", catchAll, opt)})
+			g.Desc(name).Eq(err.Error(), "the catch-all function at '/a' does not support the option "+name)
+		}
+
+		_, err := NewHandler([]Function{FuncCatchAll("/a", catchAll, MaxBodyBytes(10))})
+		g.Nil(err)
+	})
+
+	t.Run("authentication", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler([]Function{
+			FuncCatchAll("/a", func(ctx context.Context, path string, body []byte) ([]byte, error) {
+				return []byte(Principal(ctx)), nil
+			}),
+		}, WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+			if r.Header.Get("X-Api-Key") != "secret" {
+				return nil, errors.New("invalid api key")
+			}
+			return WithPrincipal(ctx, "alice"), nil
+		}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/a/b", nil))
+		g.Eq(w.Code, http.StatusUnauthorized)
+		g.Has(w.Body.String(), "invalid api key")
+
+		r := httptest.NewRequest(http.MethodPost, "/a/b", nil)
+		r.Header.Set("X-Api-Key", "secret")
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		g.Eq(w.Body.String(), "alice")
+	})
+
+	t.Run("max body bytes", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler([]Function{
+			FuncCatchAll("/a", func(ctx context.Context, path string, body []byte) ([]byte, error) {
+				return body, nil
+			}, MaxBodyBytes(3)),
+		})
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/a/b", strings.NewReader("abc")))
+		g.Eq(w.Body.String(), "abc")
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/a/b", strings.NewReader("abcd")))
+		g.Eq(w.Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,29 @@
+package expose
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrConcurrencyLimit is returned, when a call is rejected, because the maximum of concurrent calls is reached (see [WithMaxConcurrency]).
+// The handler responds with 503 Service Unavailable and the call is [Retryable].
+var ErrConcurrencyLimit = errors.New("too many concurrent calls")
+
+// concurrencyRetryAfter is the duration, that rejected clients should wait before retrying
+const concurrencyRetryAfter = time.Second
+
+// limitConcurrency runs `apply`, when the maximum of concurrent calls is not reached
+func (settings *handlerSettings) limitConcurrency(apply func() (any, error)) (any, error) {
+	if settings.concurrency == nil {
+		return apply()
+	}
+
+	select {
+	case settings.concurrency <- struct{}{}:
+		defer func() { <-settings.concurrency }()
+		return apply()
+	default:
+		return nil, SetErrRetryable(SetErrStatus(ErrConcurrencyLimit, http.StatusServiceUnavailable), concurrencyRetryAfter)
+	}
+}
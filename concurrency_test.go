@@ -0,0 +1,56 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestMaxConcurrency(t *testing.T) {
+	g := got.T(t)
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	fns := []Function{
+		FuncNullary("/work", func(ctx context.Context) (string, error) {
+			started <- struct{}{}
+			<-unblock
+			return "done", nil
+		}),
+	}
+	h, err := NewHandler(fns, WithMaxConcurrency(1))
+	g.Must().Nil(err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	first := httptest.NewRecorder()
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/work", nil))
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/work", nil))
+	g.Eq(w.Code, http.StatusServiceUnavailable)
+	g.Eq(w.Header().Get("Retry-After"), "1")
+	g.Has(w.Body.String(), ErrConcurrencyLimit.Error())
+
+	close(unblock)
+	wg.Wait()
+	g.Eq(first.Code, http.StatusOK)
+
+	// the slot is released
+	go func() { <-started }()
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/work", nil))
+	g.Eq(w.Code, http.StatusOK)
+
+	_, err = (&handlerSettings{concurrency: make(chan struct{})}).limitConcurrency(func() (any, error) { return nil, nil })
+	g.True(errors.Is(err, ErrConcurrencyLimit))
+}
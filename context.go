@@ -0,0 +1,36 @@
+package expose
+
+import "context"
+
+// contextKey is the key of context values stored with [WithContextValue].
+// Keys are distinct per type parameter, so values of different types do not collide.
+type contextKey[T any] struct{}
+
+// WithContextValue returns a copy of `ctx`, that carries `v` keyed by its type `T`.
+// Use it e.g. in an [AuthFunc] to pass the authenticated principal to the exposed functions.
+func WithContextValue[T any](ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, contextKey[T]{}, v)
+}
+
+// FromContext returns the value of type `T`, that was stored with [WithContextValue].
+// `ok` is false, when `ctx` carries no such value.
+func FromContext[T any](ctx context.Context) (v T, ok bool) {
+	v, ok = ctx.Value(contextKey[T]{}).(T)
+	return
+}
+
+// principalKey is the key of the principal, see [WithPrincipal]
+type principalKey struct{}
+
+// WithPrincipal returns a copy of `ctx`, that identifies the authenticated caller by `principal`, e.g. its user id.
+// Set it in an [AuthFunc], so that cached responses (see [Cache]) and recorded responses (see [Idempotent]) are only served
+// to the same caller.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Principal returns the principal of the authenticated caller, that was set with [WithPrincipal]
+func Principal(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
@@ -0,0 +1,60 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestContextValue(t *testing.T) {
+	g := got.T(t)
+
+	type user struct{ Name string }
+	type tenant string
+
+	ctx := WithContextValue(context.Background(), user{Name: "alice"})
+	ctx = WithContextValue(ctx, tenant("acme"))
+
+	u, ok := FromContext[user](ctx)
+	g.True(ok)
+	g.Eq(u, user{Name: "alice"})
+
+	tn, ok := FromContext[tenant](ctx)
+	g.True(ok)
+	g.Eq(tn, tenant("acme"))
+
+	_, ok = FromContext[*user](ctx)
+	g.False(ok)
+}
+
+func TestContextValueWithAuth(t *testing.T) {
+	g := got.T(t)
+
+	type principal struct{ Name string }
+
+	h, err := NewHandler([]Function{
+		FuncNullary("/me", func(ctx context.Context) (string, error) {
+			p, ok := FromContext[principal](ctx)
+			if !ok {
+				return "", errors.New("no principal")
+			}
+			return p.Name, nil
+		}),
+	}, WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+		return WithContextValue(ctx, principal{Name: r.Header.Get("x-user")}), nil
+	}))
+	g.Must().Nil(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/me", nil)
+	req.Header.Set("x-user", "bob")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(strings.TrimSpace(w.Body.String()), `"bob"`)
+}
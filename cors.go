@@ -0,0 +1,74 @@
+package expose
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the cross-origin resource sharing of the handler, see [WithCORS]
+type CORSConfig struct {
+	// AllowedOrigins are the origins, that may call the handler. "*" allows all origins.
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in cross-origin requests. Default: GET, POST
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in cross-origin requests. Default: Accept, Authorization, Content-Type
+	AllowedHeaders []string
+	// AllowCredentials allows cross-origin requests with credentials (cookies, authorization headers).
+	// Browsers reject the wildcard origin "*" for such requests, so the origin of the request is sent back instead.
+	AllowCredentials bool
+	// MaxAge is the duration, that browsers may cache the result of a preflight request. Zero omits the header.
+	MaxAge time.Duration
+}
+
+// newCORSMiddleware creates the [Middleware], that implements the `cfg`.
+// Preflight requests of allowed origins are answered directly with 204 No Content.
+func newCORSMiddleware(cfg CORSConfig) Middleware {
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodPost}
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = []string{"Accept", "Authorization", "Content-Type"}
+	}
+	anyOrigin := slices.Contains(cfg.AllowedOrigins, "*")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			w.Header().Add("Vary", "Origin")
+
+			if origin == "" || !(anyOrigin || slices.Contains(cfg.AllowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin && !cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestCORS(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}),
+	}
+	denyAll := WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+		return ctx, errors.New("unauthorized")
+	})
+
+	newRequest := func(method, origin string) *http.Request {
+		r := httptest.NewRequest(method, "/ping", nil)
+		r.Header.Set("Origin", origin)
+		return r
+	}
+
+	t.Run("preflight", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, denyAll, WithCORS(CORSConfig{
+			AllowedOrigins: []string{"https://app.example.com"},
+			MaxAge:         time.Hour,
+		}))
+		g.Must().Nil(err)
+
+		r := newRequest(http.MethodOptions, "https://app.example.com")
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusNoContent)
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+		g.Eq(w.Header().Get("Access-Control-Allow-Methods"), "GET, POST")
+		g.Eq(w.Header().Get("Access-Control-Allow-Headers"), "Accept, Authorization, Content-Type")
+		g.Eq(w.Header().Get("Access-Control-Max-Age"), "3600")
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithCORS(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(http.MethodPost, "https://evil.example.com"))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "")
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithCORS(CORSConfig{AllowedOrigins: []string{"*"}}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(http.MethodPost, "https://app.example.com"))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		g.Eq(w.Header().Get("Access-Control-Allow-Credentials"), "")
+	})
+
+	t.Run("wildcard with credentials", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithCORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, newRequest(http.MethodPost, "https://app.example.com"))
+
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "https://app.example.com")
+		g.Eq(w.Header().Get("Access-Control-Allow-Credentials"), "true")
+		g.Eq(w.Header().Values("Vary"), []string{"Origin"})
+	})
+}
@@ -0,0 +1,103 @@
+package expose
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// debugInfo is the effective configuration of a [Handler], served by the debug endpoint (see [WithDebugEndpoint])
+type debugInfo struct {
+	Functions     []debugFunction `json:"functions"`
+	Encodings     []string        `json:"encodings"`
+	Middlewares   int             `json:"middlewares"`
+	Interceptors  int             `json:"interceptors"`
+	BasePath      string          `json:"basePath,omitempty"`
+	SwaggerPath   string          `json:"swaggerPath,omitempty"`
+	SwaggerUIPath string          `json:"swaggerUIPath,omitempty"`
+	ErrorHandler  bool            `json:"errorHandler"`
+	Auth          bool            `json:"auth"`
+	Tracing       bool            `json:"tracing"`
+	Logger        bool            `json:"logger"`
+	// ValidateResponses reports the response validation, see [WithResponseValidation]
+	ValidateResponses bool `json:"validateResponses"`
+	// Timeout is the timeout of the calls, see [WithTimeout]
+	Timeout string `json:"timeout,omitempty"`
+	// TimeoutHeader and MaxHeaderTimeout are the timeouts of the clients, see [WithHeaderTimeout]
+	TimeoutHeader    string `json:"timeoutHeader,omitempty"`
+	MaxHeaderTimeout string `json:"maxHeaderTimeout,omitempty"`
+	// MaxBodyBytes limits the request bodies, see [WithMaxBodyBytes]
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+	// MaxConcurrency limits the concurrent calls, see [WithMaxConcurrency]
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+}
+
+type debugFunction struct {
+	Path           string   `json:"path"`
+	Module         string   `json:"module"`
+	Name           string   `json:"name"`
+	Aliases        []string `json:"aliases,omitempty"`
+	Methods        []string `json:"methods,omitempty"`
+	Validate       bool     `json:"validate"`
+	CircuitBreaker string   `json:"circuitBreaker,omitempty"`
+	// MaxBodyBytes overrides the limit of the handler, see [MaxBodyBytes]
+	MaxBodyBytes int64 `json:"maxBodyBytes,omitempty"`
+}
+
+// newDebugHandler serves the effective configuration of the handler as JSON.
+// The circuit breaker states are read on every request.
+func newDebugHandler(settings *handlerSettings, fns []Function) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info := debugInfo{
+			Middlewares:   len(settings.firstMiddlewares) + len(settings.middlewares) + len(settings.lastMiddlewares),
+			Interceptors:  len(settings.interceptors),
+			BasePath:      settings.basePath,
+			SwaggerPath:   settings.swaggerPath,
+			SwaggerUIPath: settings.swaggerUIPath,
+			ErrorHandler:  settings.errorHandler != nil,
+			Auth:          settings.auth != nil,
+			Tracing:       settings.tracer != nil,
+			Logger:        settings.logger != nil,
+
+			ValidateResponses: settings.validateResponses,
+			TimeoutHeader:     settings.timeoutHeader,
+			MaxBodyBytes:      settings.maxBodyBytes,
+			MaxConcurrency:    cap(settings.concurrency),
+		}
+		if settings.timeout > 0 {
+			info.Timeout = settings.timeout.String()
+		}
+		if settings.timeoutHeader != "" && settings.maxHeaderTimeout > 0 {
+			info.MaxHeaderTimeout = settings.maxHeaderTimeout.String()
+		}
+
+		for mimeType := range settings.encoding {
+			info.Encodings = append(info.Encodings, mimeType)
+		}
+		sort.Strings(info.Encodings)
+
+		for _, fn := range fns {
+			fnSettings := getFuncSettings(fn)
+			f := debugFunction{
+				Path:     fn.Path(),
+				Module:   fn.Module(),
+				Name:     fn.Name(),
+				Aliases:  fnSettings.aliases,
+				Methods:  fnSettings.methods,
+				Validate: fnSettings.validate,
+
+				MaxBodyBytes: fnSettings.maxBodyBytes,
+			}
+			if fnSettings.breaker != nil {
+				f.CircuitBreaker = fnSettings.breaker.State().String()
+			}
+			info.Functions = append(info.Functions, f)
+		}
+
+		w.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(w).Encode(info); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestDebugEndpoint(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}, Validate(true), Aliases("/inc"), MaxBodyBytes(16)),
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/expose", nil))
+
+		g.Eq(w.Code, http.StatusNotFound)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithDebugEndpoint("/debug/expose"), WithEncodings(Encoding{MimeType: "application/xml"}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/expose", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+
+		var info debugInfo
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &info))
+
+		g.Eq(info.Encodings, []string{"*/*", "application/json", "application/xml"})
+		g.Eq(info.Functions, []debugFunction{
+			{Path: "/counter/get", Module: "counter", Name: "get"},
+			{Path: "/counter/inc", Module: "counter", Name: "inc", Aliases: []string{"/inc"}, Validate: true, MaxBodyBytes: 16},
+		})
+		g.Eq(info.Timeout, "")
+		g.Eq(info.MaxConcurrency, 0)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithDebugEndpoint("/debug/expose"), WithTimeout(5*time.Second), WithHeaderTimeout("X-Timeout-Ms", time.Minute),
+			WithMaxBodyBytes(1024), WithMaxConcurrency(8), WithResponseValidation(true))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/expose", nil))
+
+		var info debugInfo
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &info))
+		g.True(info.ValidateResponses)
+		g.Eq(info.Timeout, "5s")
+		g.Eq(info.TimeoutHeader, "X-Timeout-Ms")
+		g.Eq(info.MaxHeaderTimeout, "1m0s")
+		g.Eq(info.MaxBodyBytes, int64(1024))
+		g.Eq(info.MaxConcurrency, 8)
+	})
+}
@@ -2,7 +2,15 @@ package expose
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Encoding is used for content negotiating. Request arguments and response values are encoded and decoded
@@ -10,9 +18,24 @@ import (
 type Encoding struct {
 	MimeType   string
 	GetDecoder func(r io.Reader) Decoder
+	// GetDecoderWithRequest creates the decoder of a request, when the decoding depends on the request headers,
+	// e.g. on the charset of the "Content-Type". It takes precedence over GetDecoder.
+	// The body of the request must be read from `r.Body`, which is limited by [WithMaxBodyBytes].
+	GetDecoderWithRequest func(r *http.Request) Decoder
+	// GetEncoder is nil for encodings, that only decode requests
 	GetEncoder func(w io.Writer) Encoder
 }
 
+// decoder creates the decoder of the request `r`, whose body is read from `body`
+func (enc Encoding) decoder(r *http.Request, body io.Reader) Decoder {
+	if enc.GetDecoderWithRequest == nil {
+		return enc.GetDecoder(body)
+	}
+	r = r.WithContext(r.Context())
+	r.Body = io.NopCloser(body)
+	return enc.GetDecoderWithRequest(r)
+}
+
 type Decoder interface {
 	Decode(v any) error
 }
@@ -32,19 +55,220 @@ func (f EncoderFunc) Encode(v any) error {
 	return f(v)
 }
 
-var JsonEncoding = Encoding{
-	MimeType: "application/json",
-	GetEncoder: func(w io.Writer) Encoder {
-		enc := json.NewEncoder(w)
-		return EncoderFunc(func(v any) error {
-			return enc.Encode(v)
-		})
-	},
-	GetDecoder: func(r io.Reader) Decoder {
-		dec := json.NewDecoder(r)
-
-		return DecoderFunc(func(v any) error {
-			return dec.Decode(v)
-		})
-	},
+// JsonEncoding is the default 'application/json' [Encoding]
+var JsonEncoding = NewJSONEncoding()
+
+// specEncoding pretty prints the served spec
+var specEncoding = NewJSONEncoding(JSONEscapeHTML(false), JSONIndent("", "  "))
+
+type jsonSettings struct {
+	escapeHTML     bool
+	prefix, indent string
+}
+
+// JSONOption configures the encoding created with [NewJSONEncoding]
+type JSONOption func(s *jsonSettings)
+
+// JSONEscapeHTML sets whether `<`, `>` and `&` in strings are escaped. Default: true
+func JSONEscapeHTML(escape bool) JSONOption {
+	return func(s *jsonSettings) {
+		s.escapeHTML = escape
+	}
+}
+
+// JSONIndent indents the encoded values, see [json.Encoder.SetIndent]
+func JSONIndent(prefix, indent string) JSONOption {
+	return func(s *jsonSettings) {
+		s.prefix = prefix
+		s.indent = indent
+	}
+}
+
+// NewJSONEncoding creates an 'application/json' [Encoding]. Without options, it matches [JsonEncoding].
+func NewJSONEncoding(opts ...JSONOption) Encoding {
+	settings := jsonSettings{escapeHTML: true}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return Encoding{
+		MimeType: "application/json",
+		GetEncoder: func(w io.Writer) Encoder {
+			enc := json.NewEncoder(w)
+			enc.SetEscapeHTML(settings.escapeHTML)
+			enc.SetIndent(settings.prefix, settings.indent)
+			return EncoderFunc(func(v any) error {
+				return enc.Encode(v)
+			})
+		},
+		GetDecoder: func(r io.Reader) Decoder {
+			dec := json.NewDecoder(r)
+
+			return DecoderFunc(func(v any) error {
+				return jsonDecodeError(dec.Decode(v))
+			})
+		},
+	}
+}
+
+// FieldError describes a value of the request, that does not match the type of its field.
+// Error responses include the path of the field and the expected type as `field` and `expected`.
+type FieldError struct {
+	// Field is the dot separated path of the field, e.g. `address.zip`. It is empty, when the whole request has the wrong type.
+	Field string
+	// Expected is the JSON type of the field, e.g. `number`
+	Expected string
+	// Actual is the JSON type of the value
+	Actual string
+
+	err error
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("expected %s, got %s", e.Expected, e.Actual)
+	}
+	return fmt.Sprintf("field `%s` expected %s, got %s", e.Field, e.Expected, e.Actual)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.err
+}
+
+// jsonDecodeError translates type mismatches into a [FieldError], that names the offending field.
+// The [json.UnmarshalTypeError] itself only reports the go struct field.
+func jsonDecodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+
+	return &FieldError{
+		Field:    typeErr.Field,
+		Expected: jsonTypeName(typeErr.Type),
+		Actual:   typeErr.Value,
+		err:      err,
+	}
+}
+
+// jsonTypeName returns the name of the json type, that `t` is decoded from
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return t.String()
+	}
+}
+
+// negotiateEncoding selects the registered encoding, that matches the `Accept` header best.
+// The media ranges of the header are tried in the order of their quality values, more specific ranges first.
+// A range like `application/*` matches any registered encoding of that type, `*/*` selects the encoding
+// registered for `*/*` or any other registered encoding. Ranges with a quality of 0 exclude the types, they match
+// more specifically than the other ranges, e.g. `application/json;q=0, */*` accepts any type but JSON.
+// Encodings, that only decode (e.g. [FormEncoding]), are skipped.
+func negotiateEncoding(encodings map[string]Encoding, accept string) (Encoding, bool) {
+	ranges := parseAccept(accept)
+
+	// acceptable reports whether the most specific range, that matches `mimeType`, has a quality above 0
+	acceptable := func(mimeType string) bool {
+		specificity, q := -1, 0.0
+		for _, r := range ranges {
+			if r.specificity > specificity && r.matches(mimeType) {
+				specificity, q = r.specificity, r.q
+			}
+		}
+		return q > 0
+	}
+
+	mimeTypes := make([]string, 0, len(encodings))
+	for mimeType := range encodings {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+
+	for _, mediaRange := range ranges {
+		if mediaRange.q <= 0 {
+			// the excluded ranges are ordered last
+			break
+		}
+		if enc, ok := encodings[mediaRange.mediaType]; ok && enc.GetEncoder != nil && acceptable(enc.MimeType) {
+			return enc, true
+		}
+		if mediaRange.specificity == 2 {
+			continue
+		}
+
+		for _, mimeType := range mimeTypes {
+			if mimeType == mediaRange.mediaType || !mediaRange.matches(mimeType) {
+				continue
+			}
+			if enc := encodings[mimeType]; enc.GetEncoder != nil && acceptable(enc.MimeType) {
+				return enc, true
+			}
+		}
+	}
+	return Encoding{}, false
+}
+
+// mediaRange is a media range of an `Accept` header
+type mediaRange struct {
+	mediaType string
+	q         float64
+	// specificity is 0 for `*/*`, 1 for ranges like `text/*` and 2 for media types
+	specificity int
+}
+
+// matches reports whether the media range includes the media type `mimeType`
+func (r mediaRange) matches(mimeType string) bool {
+	switch r.specificity {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mimeType, strings.TrimSuffix(r.mediaType, "*"))
+	default:
+		return r.mediaType == mimeType
+	}
+}
+
+// parseAccept returns the media ranges of an `Accept` header, ordered by their quality (highest first)
+// and specificity. Parameters are stripped. Ranges with a quality of 0 are kept, since they exclude the types they match.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if qStr, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, max(q, 0), 2 - strings.Count(mediaType, "*")})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return ranges[i].specificity > ranges[j].specificity
+	})
+	return ranges
 }
@@ -0,0 +1,119 @@
+package expose
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	encoder := func(w io.Writer) Encoder { return nil }
+	xml := Encoding{MimeType: "application/xml", GetEncoder: encoder}
+	yaml := Encoding{MimeType: "text/yaml", GetEncoder: encoder}
+
+	encodings := map[string]Encoding{
+		"*/*":              JsonEncoding,
+		"application/json": JsonEncoding,
+		"application/xml":  xml,
+		"text/yaml":        yaml,
+		// decode-only encodings are never negotiated
+		FormEncoding.MimeType: FormEncoding,
+	}
+
+	tests := []struct {
+		accept   string
+		expected string
+		found    bool
+	}{
+		{"application/json", "application/json", true},
+		{"application/xml", "application/xml", true},
+		{"application/xml;q=0.5, text/yaml", "text/yaml", true},
+		{"application/json, */*;q=0.8", "application/json", true},
+		{"text/html, */*;q=0.8", "application/json", true},
+		{"*/*, text/yaml", "text/yaml", true},
+		{"text/*", "text/yaml", true},
+		{"application/*", "application/json", true},
+		{"text/html;level=1, application/xml;q=0.9", "application/xml", true},
+		{"application/json;q=0, application/xml", "application/xml", true},
+		{"application/json;q=0", "", false},
+		{"application/json;q=0, */*", "application/xml", true},
+		{"application/*;q=0, */*", "text/yaml", true},
+		{"application/json;q=0, application/*", "application/xml", true},
+		{"*/*;q=0, application/json", "application/json", true},
+		{"*/*;q=0", "", false},
+		{"application/x-www-form-urlencoded, application/xml;q=0.5", "application/xml", true},
+		{"application/x-www-form-urlencoded", "", false},
+		{"text/html", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			g := got.T(t)
+
+			enc, found := negotiateEncoding(encodings, tt.accept)
+
+			g.Eq(found, tt.found)
+			g.Eq(enc.MimeType, tt.expected)
+		})
+	}
+}
+
+func TestJsonDecodeFieldError(t *testing.T) {
+	type address struct {
+		Zip int `json:"zip"`
+	}
+	type person struct {
+		Age     int     `json:"age"`
+		Address address `json:"address"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"field", `{"age":"42"}`, "field `age` expected number, got string"},
+		{"nested field", `{"address":{"zip":true}}`, "field `address.zip` expected number, got bool"},
+		{"object", `{"address":[]}`, "field `address` expected object, got array"},
+		{"request", `"42"`, "expected object, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := got.T(t)
+
+			var p person
+			err := JsonEncoding.GetDecoder(strings.NewReader(tt.body)).Decode(&p)
+
+			g.Must().NotNil(err)
+			g.Eq(err.Error(), tt.expected)
+
+			var fieldErr *FieldError
+			g.True(errors.As(err, &fieldErr))
+		})
+	}
+}
+
+func TestNewJSONEncoding(t *testing.T) {
+	v := map[string]string{"html": "<b>&</b>"}
+
+	t.Run("default", func(t *testing.T) {
+		g := got.T(t)
+		var buf bytes.Buffer
+		g.Must().Nil(NewJSONEncoding().GetEncoder(&buf).Encode(v))
+		g.Eq(buf.String(), "{\"html\":\"\\u003cb\\u003e\\u0026\\u003c/b\\u003e\"}\n")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		g := got.T(t)
+		var buf bytes.Buffer
+		enc := NewJSONEncoding(JSONEscapeHTML(false), JSONIndent("", "  "))
+		g.Must().Nil(enc.GetEncoder(&buf).Encode(v))
+		g.Eq(buf.String(), "{\n  \"html\": \"<b>&</b>\"\n}\n")
+	})
+}
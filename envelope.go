@@ -0,0 +1,99 @@
+package expose
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// WithResponseEnvelope wraps the results of all functions with `envelope` right before they are encoded,
+// e.g. to respond with `{"data": <result>, "meta": {...}}`.
+//
+// The response schemas in the spec describe the envelope: `envelope` is called with the zero value of each result
+// and the fields (or keys of a `map[string]any`) of type `any`, that hold the result, are documented with its schema.
+// The envelope should therefore always return the same shape.
+//
+// Functions without a result ([Void]) respond without a body, so their results are not wrapped.
+// [Blob]s are streamed as they are and error responses are not wrapped either.
+func WithResponseEnvelope(envelope func(res any) any) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.envelope = envelope
+	}
+}
+
+// reflectEnvelope reflects the schema of the envelope `env`, that wraps the result `res` with the schema `resSchema`.
+// The envelope is described inline, since it is shared by functions with different results.
+func reflectEnvelope(env any, res any, resSchema *openapi3.SchemaRef, schemas openapi3.Schemas, settings reflectSettings) (*openapi3.SchemaRef, error) {
+	resType := reflect.TypeOf(res)
+
+	// the values held by `any` fields are reflected by their dynamic type
+	reflectValue := func(v reflect.Value) (*openapi3.SchemaRef, error) {
+		val := v.Interface()
+		t := reflect.TypeOf(val)
+		if t == resType {
+			return resSchema, nil
+		}
+		named := t
+		if named.Kind() == reflect.Pointer {
+			named = named.Elem()
+		}
+		if named.Kind() == reflect.Struct && named.Name() != "" {
+			return reflectSchema(val, schemas, settings)
+		}
+		return generateSchema(val, schemas, settings)
+	}
+
+	v := reflect.ValueOf(env)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case !v.IsValid():
+		return openapi3.NewSchemaRef("", openapi3.NewSchema()), nil
+	case v.Kind() == reflect.Struct:
+		ref, err := generateSchema(v.Interface(), schemas, settings)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			field := v.Field(i)
+			if !f.IsExported() || f.Anonymous || f.Type.Kind() != reflect.Interface || field.IsNil() {
+				continue
+			}
+			name := settings.fieldNames.name(f)
+			if name == "-" {
+				continue
+			}
+			if ref.Value.Properties[name], err = reflectValue(field.Elem()); err != nil {
+				return nil, err
+			}
+		}
+		return ref, nil
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String && v.Type().Elem().Kind() == reflect.Interface:
+		obj := openapi3.NewObjectSchema()
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key()))
+			if value.IsNil() {
+				obj.Properties[k] = openapi3.NewSchemaRef("", openapi3.NewSchema())
+				continue
+			}
+			prop, err := reflectValue(value.Elem())
+			if err != nil {
+				return nil, err
+			}
+			obj.Properties[k] = prop
+			obj.Required = append(obj.Required, k)
+		}
+		return openapi3.NewSchemaRef("", obj), nil
+	default:
+		return generateSchema(env, schemas, settings)
+	}
+}
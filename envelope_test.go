@@ -0,0 +1,87 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+type envelopeUser struct {
+	Name string `json:"name"`
+}
+
+type envelopeMeta struct {
+	Version int `json:"version"`
+}
+
+type envelope struct {
+	Data any          `json:"data"`
+	Meta envelopeMeta `json:"meta"`
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/users/get", func(ctx context.Context) (envelopeUser, error) {
+			return envelopeUser{Name: "alice"}, nil
+		}),
+		FuncNullaryVoid("/users/touch", func(ctx context.Context) error {
+			return nil
+		}),
+	}
+	wrap := WithResponseEnvelope(func(res any) any {
+		return envelope{Data: res, Meta: envelopeMeta{Version: 1}}
+	})
+
+	t.Run("wraps results", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, wrap, WithResponseValidation(true))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/get", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), `{"data":{"name":"alice"},"meta":{"version":1}}`)
+	})
+
+	t.Run("void results have no body", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, wrap)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/touch", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "")
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns, wrap)
+		g.Must().Nil(err)
+
+		schema := spec.Paths.Find("/users/get").Post.Responses.Status(200).Value.Content.Get("application/json").Schema
+		g.Eq(schema.Ref, "")
+		g.Eq(schema.Value.Properties["data"].Ref, "#/components/schemas/github.com.pbedat.expose.envelopeUser")
+		g.Eq(schema.Value.Properties["meta"].Ref, "#/components/schemas/github.com.pbedat.expose.envelopeMeta")
+		g.Eq(schema.Value.Required, []string{"data", "meta"})
+	})
+
+	t.Run("map envelope", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns, WithResponseEnvelope(func(res any) any {
+			return map[string]any{"data": res, "count": 1}
+		}))
+		g.Must().Nil(err)
+
+		schema := spec.Paths.Find("/users/get").Post.Responses.Status(200).Value.Content.Get("application/json").Schema
+		g.Eq(schema.Value.Properties["data"].Ref, "#/components/schemas/github.com.pbedat.expose.envelopeUser")
+		g.Eq(schema.Value.Properties["count"].Value.Type.Is("integer"), true)
+		g.Eq(schema.Value.Required, []string{"count", "data"})
+	})
+}
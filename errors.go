@@ -1,6 +1,13 @@
 package expose
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+// ErrDecode is wrapped by the errors of requests, whose body can not be decoded, e.g. malformed JSON.
+// They are client errors and are answered with 400 Bad Request, unless the decoder set another status (see [SetErrStatus]).
+var ErrDecode = errors.New("invalid request body")
 
 type ErrWithCode struct {
 	code string
@@ -15,6 +22,10 @@ func (e ErrWithCode) Error() string {
 	return e.err.Error()
 }
 
+func (e ErrWithCode) Unwrap() error {
+	return e.err
+}
+
 type WithCode interface {
 	error
 	Code() string
@@ -32,3 +43,85 @@ func GetErrCode(err error) (string, bool) {
 
 	return "", false
 }
+
+type ErrRetryable struct {
+	retryAfter time.Duration
+	err        error
+}
+
+func (e ErrRetryable) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+func (e ErrRetryable) Error() string {
+	return e.err.Error()
+}
+
+func (e ErrRetryable) Unwrap() error {
+	return e.err
+}
+
+// Retryable is implemented by errors, that mark the failed call as safe to retry.
+// The error response contains `retryable: true` and a `Retry-After` header, when the duration is known.
+type Retryable interface {
+	error
+	// RetryAfter returns how long clients should wait before retrying. 0 when unknown.
+	RetryAfter() time.Duration
+}
+
+// SetErrRetryable marks `err` as [Retryable]. Optionally provide the duration clients should wait before retrying.
+func SetErrRetryable(err error, retryAfter ...time.Duration) error {
+	e := &ErrRetryable{err: err}
+	if len(retryAfter) > 0 {
+		e.retryAfter = retryAfter[0]
+	}
+	return e
+}
+
+// GetErrRetryable reports whether `err` is [Retryable] and returns how long to wait before retrying.
+func GetErrRetryable(err error) (time.Duration, bool) {
+	var retryable Retryable
+	if errors.As(err, &retryable) {
+		return retryable.RetryAfter(), true
+	}
+
+	return 0, false
+}
+
+type ErrWithStatus struct {
+	status int
+	err    error
+}
+
+func (e ErrWithStatus) Status() int {
+	return e.status
+}
+
+func (e ErrWithStatus) Error() string {
+	return e.err.Error()
+}
+
+func (e ErrWithStatus) Unwrap() error {
+	return e.err
+}
+
+// WithStatus is implemented by errors, that define the http status of their error response.
+type WithStatus interface {
+	error
+	Status() int
+}
+
+// SetErrStatus sets the http `status` of the error response for `err`
+func SetErrStatus(err error, status int) error {
+	return &ErrWithStatus{status: status, err: err}
+}
+
+// GetErrStatus returns the http status of `err`, when it carries one. See [WithStatus].
+func GetErrStatus(err error) (int, bool) {
+	var withStatus WithStatus
+	if errors.As(err, &withStatus) {
+		return withStatus.Status(), true
+	}
+
+	return 0, false
+}
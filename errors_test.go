@@ -0,0 +1,215 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestErrRetryable(t *testing.T) {
+	errUnavailable := errors.New("downstream unavailable")
+
+	h, err := NewHandler([]Function{
+		FuncNullaryVoid("/retryable", func(ctx context.Context) error {
+			return SetErrRetryable(errUnavailable, 2*time.Second)
+		}),
+		FuncNullaryVoid("/fatal", func(ctx context.Context) error {
+			return errUnavailable
+		}),
+	})
+	got.T(t).Must().Nil(err)
+
+	t.Run("marked", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/retryable", nil))
+
+		g.Eq(w.Code, http.StatusInternalServerError)
+		g.Eq(w.Header().Get("Retry-After"), "2")
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["retryable"], true)
+		g.Eq(body["message"], "downstream unavailable")
+	})
+
+	t.Run("unmarked", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fatal", nil))
+
+		g.Eq(w.Header().Get("Retry-After"), "")
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["retryable"], nil)
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		g := got.T(t)
+		g.Is(SetErrRetryable(errUnavailable), errUnavailable)
+
+		retryAfter, ok := GetErrRetryable(SetErrRetryable(errUnavailable))
+		g.True(ok)
+		g.Eq(retryAfter, time.Duration(0))
+	})
+}
+
+func TestErrorStatus(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	fns := []Function{
+		FuncNullaryVoid("/app", func(ctx context.Context) error {
+			return fmt.Errorf("%w: invalid input", ErrApplication)
+		}),
+		FuncNullaryVoid("/internal", func(ctx context.Context) error {
+			return errors.New("boom")
+		}),
+		FuncNullaryVoid("/custom", func(ctx context.Context) error {
+			return SetErrStatus(errNotFound, http.StatusNotFound)
+		}),
+	}
+
+	call := func(h http.Handler, path string) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w.Code
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		g.Eq(call(h, "/app"), http.StatusUnprocessableEntity)
+		g.Eq(call(h, "/internal"), http.StatusInternalServerError)
+		g.Eq(call(h, "/custom"), http.StatusNotFound)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithDefaultErrorStatus(http.StatusBadRequest, http.StatusBadGateway))
+		g.Must().Nil(err)
+
+		g.Eq(call(h, "/app"), http.StatusBadRequest)
+		g.Eq(call(h, "/internal"), http.StatusBadGateway)
+		g.Eq(call(h, "/custom"), http.StatusNotFound)
+	})
+
+	t.Run("error handler overrides", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns,
+			WithDefaultErrorStatus(http.StatusBadRequest, http.StatusBadGateway),
+			WithErrorHandler(func(w http.ResponseWriter, enc Encoder, err error) bool {
+				w.WriteHeader(http.StatusTeapot)
+				return true
+			}))
+		g.Must().Nil(err)
+
+		g.Eq(call(h, "/custom"), http.StatusTeapot)
+	})
+}
+
+func TestErrorCodeStatus(t *testing.T) {
+	g := got.T(t)
+
+	h, err := NewHandler([]Function{
+		FuncNullaryVoid("/users/get", func(ctx context.Context) error {
+			return SetErrCode(errors.New("user not found"), "not_found")
+		}),
+		FuncNullaryVoid("/users/save", func(ctx context.Context) error {
+			return SetErrCode(fmt.Errorf("%w: invalid name", ErrApplication), "invalid_name")
+		}),
+	}, WithErrorCodeStatus(map[string]int{"not_found": http.StatusNotFound, "conflict": http.StatusConflict}))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/get", nil))
+	g.Eq(w.Code, http.StatusNotFound)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/save", nil))
+	g.Eq(w.Code, http.StatusUnprocessableEntity)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	var spec openapi3.T
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &spec))
+
+	responses := spec.Paths.Find("/users/get").Post.Responses
+	g.Eq(*responses.Status(http.StatusNotFound).Value.Description, "not_found")
+	g.Eq(*responses.Status(http.StatusConflict).Value.Description, "conflict")
+}
+
+func TestErrorFormatter(t *testing.T) {
+	fns := []Function{
+		FuncNullaryVoid("/users/get", func(ctx context.Context) error {
+			return &dbError{Query: "SELECT * FROM users"}
+		}),
+	}
+	opts := []HandlerOption{
+		WithDefaultErrorStatus(http.StatusUnprocessableEntity, http.StatusServiceUnavailable),
+		WithRequestID("X-Request-Id"),
+		WithErrorFormatter(func(ctx context.Context, err error) any {
+			return map[string]string{"error": err.Error(), "requestId": RequestID(ctx)}
+		}),
+	}
+
+	t.Run("replaces the body", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, opts...)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/users/get", nil)
+		r.Header.Set("X-Request-Id", "req-1")
+		h.ServeHTTP(w, r)
+		g.Eq(w.Code, http.StatusServiceUnavailable)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body, map[string]any{"error": "query failed", "requestId": "req-1"})
+	})
+
+	t.Run("default body leaks the fields", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, opts[0])
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/get", nil))
+		g.Has(w.Body.String(), "SELECT * FROM users")
+	})
+
+	t.Run("error handler takes precedence", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, append(opts, WithErrorHandler(func(w http.ResponseWriter, enc Encoder, err error) bool {
+			w.WriteHeader(http.StatusTeapot)
+			return true
+		}))...)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/get", nil))
+		g.Eq(w.Code, http.StatusTeapot)
+		g.Eq(w.Body.Len(), 0)
+	})
+}
+
+type dbError struct {
+	Query string
+}
+
+func (err *dbError) Error() string {
+	return "query failed"
+}
@@ -0,0 +1,96 @@
+package expose
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ExamplePair is a named example of a function, see [WithExamples]
+type ExamplePair struct {
+	Summary     string
+	Description string
+	// Request is documented as example of the request body, when it is not nil
+	Request any
+	// Response is documented as example of the successful response, when it is not nil
+	Response any
+}
+
+// WithExamples documents the named `examples` of the function, e.g. a valid call, an edge case and a failing call.
+// Each example is added under its name to the examples of the request body and the successful JSON response,
+// so that the Swagger UI offers them in a dropdown. The examples are encoded like the requests and responses,
+// i.e. with the field names of the handler (see [WithFieldNames]) and wrapped in the response envelope (see [WithResponseEnvelope]).
+// The examples are ordered by their name, so that the spec is deterministic. Repeated options add to the examples.
+func WithExamples(examples map[string]ExamplePair) FuncOpt {
+	return func(s *functionSettings) {
+		if s.examples == nil {
+			s.examples = map[string]ExamplePair{}
+		}
+		for name, example := range examples {
+			s.examples[name] = example
+		}
+	}
+}
+
+// addExamples adds the examples of `fn` (see [WithExamples]) to the request body and the successful response of `op`
+func (settings reflectSettings) addExamples(fn Function, op *openapi3.Operation) error {
+	examples := getFuncSettings(fn).examples
+	if len(examples) == 0 {
+		return nil
+	}
+
+	var reqContent, resContent *openapi3.MediaType
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		reqContent = op.RequestBody.Value.Content.Get(requestMediaType(fn.Req()))
+	}
+	if res := op.Responses.Value("200"); res != nil && res.Value != nil {
+		resContent = res.Value.Content.Get("application/json")
+	}
+
+	for name, example := range examples {
+		if example.Request != nil && reqContent != nil {
+			value, err := settings.exampleValue(example.Request)
+			if err != nil {
+				return fmt.Errorf("%s: failed to encode the request of the example '%s': %w", fn.Path(), name, err)
+			}
+			addExample(reqContent, name, example, value)
+		}
+		if example.Response != nil && resContent != nil {
+			res := example.Response
+			if settings.envelope != nil {
+				res = settings.envelope(res)
+			}
+			value, err := settings.exampleValue(res)
+			if err != nil {
+				return fmt.Errorf("%s: failed to encode the response of the example '%s': %w", fn.Path(), name, err)
+			}
+			addExample(resContent, name, example, value)
+		}
+	}
+	return nil
+}
+
+// exampleValue encodes `v` like the JSON encoding of the handler,
+// so that the example shows the field names on the wire
+func (settings reflectSettings) exampleValue(v any) (any, error) {
+	data, err := settings.fieldNames.marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func addExample(mediaType *openapi3.MediaType, name string, example ExamplePair, value any) {
+	if mediaType.Examples == nil {
+		mediaType.Examples = openapi3.Examples{}
+	}
+	ex := openapi3.NewExample(value)
+	ex.Summary = example.Summary
+	ex.Description = example.Description
+	mediaType.Examples[name] = &openapi3.ExampleRef{Value: ex}
+}
@@ -31,11 +31,6 @@ func main() {
 			Info: &openapi3.Info{
 				Title: "Starter Example",
 			},
-			Servers: openapi3.Servers{
-				&openapi3.Server{
-					URL: "http://localhost:8000/rpc",
-				},
-			},
 		}),
 		expose.WithSwaggerUI("/swagger-ui"),
 	)
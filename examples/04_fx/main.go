@@ -22,12 +22,33 @@ func Get(context.Context, expose.Void) (int, error) {
 	return int(i.Load()), nil
 }
 
+// clicks is a router with its own counter. Its functions are exposed under '/clicks'.
+type clicks struct {
+	n atomic.Int32
+}
+
+func newClicks() *clicks {
+	return &clicks{}
+}
+
+func (c *clicks) Expose() []expose.Function {
+	return []expose.Function{
+		expose.FuncNullary("/click", func(context.Context) (int, error) {
+			return int(c.n.Add(1)), nil
+		}),
+		expose.FuncNullary("/count", func(context.Context) (int, error) {
+			return int(c.n.Load()), nil
+		}),
+	}
+}
+
 func main() {
 	module := fx.Options(
 		exposefx.ProvideFunc(
 			expose.Func("/inc", Inc),
 			expose.Func("/get", Get),
 		),
+		exposefx.ProvideRouterAt("/clicks", newClicks),
 		exposefx.ProvideHandler(
 			expose.WithDefaultSpec(&openapi3.T{
 				Servers: openapi3.Servers{
@@ -38,12 +59,9 @@ func main() {
 			}),
 			expose.WithPathPrefix("/rpc"),
 			expose.WithSwaggerUI("/swagger-ui")),
-		fx.Invoke(func(h *expose.Handler) {
-			http.Handle("/", h)
-
+		exposefx.ProvideServer(":8000"),
+		fx.Invoke(func(*http.Server) {
 			log.Print("listening to :8000 - swagger-ui running at http://localhost:8000/rpc/swagger-ui")
-
-			go http.ListenAndServe(":8000", nil)
 		}),
 	)
 
@@ -0,0 +1,75 @@
+package expose
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestExamples(t *testing.T) {
+	type greetReq struct {
+		UserName string
+	}
+	type greetRes struct {
+		Greeting string
+	}
+
+	fns := []Function{
+		Func("/greet", func(ctx context.Context, req greetReq) (greetRes, error) {
+			return greetRes{Greeting: "hello " + req.UserName}, nil
+		}, WithExamples(map[string]ExamplePair{
+			"valid": {Summary: "A valid greeting", Request: greetReq{UserName: "alice"}, Response: greetRes{Greeting: "hello alice"}},
+			"empty": {Summary: "An empty name", Description: "The name is not required", Request: greetReq{}},
+		}), WithExamples(map[string]ExamplePair{
+			"bob": {Request: greetReq{UserName: "bob"}},
+		})),
+	}
+
+	t.Run("named", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns)
+		g.Must().Nil(err)
+
+		op := spec.Paths.Find("/greet").Post
+		reqExamples := op.RequestBody.Value.Content.Get("application/json").Examples
+		g.Eq(len(reqExamples), 3)
+		g.Eq(reqExamples["valid"].Value.Summary, "A valid greeting")
+		g.Eq(reqExamples["valid"].Value.Value, map[string]any{"UserName": "alice"})
+		g.Eq(reqExamples["empty"].Value.Description, "The name is not required")
+
+		resExamples := op.Responses.Value("200").Value.Content.Get("application/json").Examples
+		g.Eq(len(resExamples), 1)
+		g.Eq(resExamples["valid"].Value.Value, map[string]any{"Greeting": "hello alice"})
+	})
+
+	t.Run("encoded like requests", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns, WithFieldNames(CamelCase), WithResponseEnvelope(func(res any) any {
+			return map[string]any{"data": res}
+		}))
+		g.Must().Nil(err)
+
+		op := spec.Paths.Find("/greet").Post
+		g.Eq(op.RequestBody.Value.Content.Get("application/json").Examples["valid"].Value.Value, map[string]any{"userName": "alice"})
+		g.Eq(op.Responses.Value("200").Value.Content.Get("application/json").Examples["valid"].Value.Value,
+			map[string]any{"data": map[string]any{"greeting": "hello alice"}})
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		g := got.T(t)
+		data, err := SpecJSON(fns)
+		g.Must().Nil(err)
+		for i := 0; i < 10; i++ {
+			again, err := SpecJSON(fns)
+			g.Must().Nil(err)
+			g.Eq(string(again), string(data))
+		}
+
+		// the examples are ordered by their name
+		bob, empty, valid := strings.Index(string(data), `"bob"`), strings.Index(string(data), `"empty"`), strings.Index(string(data), `"valid"`)
+		g.Lt(bob, empty)
+		g.Lt(empty, valid)
+	})
+}
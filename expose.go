@@ -2,7 +2,13 @@ package expose
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -11,6 +17,8 @@ import (
 // is then callable at the provided path.
 // If you want to expose a function without an input or output parameter, you can parametrize with [Void], use
 // [FuncVoid] or [FuncNullary] instead.
+// An empty request body is decoded as the zero value of TReq (or its defaults, see [RequestDefaults]),
+// so the request body is documented as optional. A pointer TReq points to the zero value of its element type.
 func Func[TReq any, TRes any](
 	mountpoint string,
 	fn func(ctx context.Context, req TReq) (TRes, error), opts ...FuncOpt) Function {
@@ -37,12 +45,38 @@ func newSettings(opts ...FuncOpt) functionSettings {
 
 // Void is a placeholder for input or output parameters. When an input parameter is [Void].
 // The function is treated as nullary. When the output paramtere is [Void], the function is treated as function without a return parameter.
+// Pointers to Void (*Void) and structs, that only embed Void, are treated as Void as well.
 type Void struct{}
 
 func (v *Void) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+var voidType = reflect.TypeOf(Void{})
+
+// isVoid reports whether `v` is a [Void], a pointer to a [Void] or a struct, that only embeds a [Void].
+// Such request and response types are all treated as [Void].
+func isVoid(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return false
+	}
+	if t == voidType {
+		return true
+	}
+	if t.Kind() != reflect.Struct || t.NumField() != 1 || !t.Field(0).Anonymous {
+		return false
+	}
+	embedded := t.Field(0).Type
+	if embedded.Kind() == reflect.Pointer {
+		embedded = embedded.Elem()
+	}
+	return embedded == voidType
+}
+
 // FuncVoid creates an [Function] for functions that do not return values. Shortcut for using [Func] with [Void] as request argument.
 func FuncVoid[TReq any](mountpoint string, fn func(ctx context.Context, req TReq) error, opts ...FuncOpt) Function {
 	n := mountpoint[strings.LastIndex(mountpoint, "/")+1:]
@@ -105,23 +139,184 @@ type Function interface {
 	// Res returns an empty instance of the functions result value.
 	// Used for schema reflection.
 	Res() any
-	// Apply calls the actual function by decoding the http request and passing it to the function
+	// Apply calls the actual function by decoding the http request and passing it to the function.
+	// The `spec` describes the requests for validation, it is empty when the handler does not validate.
 	Apply(ctx context.Context, dec Decoder, spec openapi3.T) (any, error)
 }
 
 type functionSettings struct {
 	validate bool
+	aliases  []string
+	security openapi3.SecurityRequirements
+	breaker  *CircuitBreaker
+	// errorResponses are documented in addition to the error responses of the handler
+	errorResponses []errorResponse
+	tags           []string
+	// headers are documented in the successful response
+	headers []responseHeader
+	// maxBodyBytes overrides the limit of the handler, see [WithMaxBodyBytes]
+	maxBodyBytes int64
+	// idempotent functions replay their responses to repeated requests, see [WithIdempotency]
+	idempotent bool
+	// blobContentTypes are the documented content types of [Blob] results
+	blobContentTypes []string
+	// protoBody documents the request and result as protobuf, see [ProtoBody]
+	protoBody bool
+	// extensions are added to the operation, see [WithExtension]
+	extensions map[string]any
+	// group is the prefix of the [Group] of the function
+	group string
+	// requestDefaults creates the value, that the request is decoded onto, see [RequestDefaults]
+	requestDefaults func() any
+	// requestDefaultsType is the type of the values, that requestDefaults creates
+	requestDefaultsType reflect.Type
+	// cacheTTL is the duration, that responses are cached for, see [Cache]
+	cacheTTL time.Duration
+	// methods are the HTTP methods, that the function answers, see [Methods]
+	methods []string
+	// examples are documented in the request body and the response, see [WithExamples]
+	examples map[string]ExamplePair
 }
 
-// Validate enables the json schema validation for requests
+type errorResponse struct {
+	status      int
+	description string
+}
+
+type responseHeader struct {
+	name        string
+	description string
+	schema      *openapi3.Schema
+}
+
+// Validate enables the json schema validation for requests. Invalid requests are rejected with 400 Bad Request.
+// String formats are validated as well, e.g. fields tagged with `format:"date"`.
 func Validate(validate bool) FuncOpt {
 	return func(s *functionSettings) {
 		s.validate = validate
 	}
 }
 
+// RequestDefaults decodes the requests of the function onto the value created by `defaults`, so that the fields, which are
+// omitted by the client, keep their default values. `TReq` must be the request type of the function, otherwise [NewHandler] fails.
+// Request types can provide their defaults themselves, by implementing [Defaulter].
+//
+// `defaults` is called for every request. When `TReq` is a pointer, the request is decoded onto a shallow copy of the pointed value,
+// so maps, slices and pointers in the defaults must not be shared between the calls of `defaults`.
+//
+// Requests are validated (see [Validate]) after the defaults are applied, so required fields with a default are never missing.
+func RequestDefaults[TReq any](defaults func() TReq) FuncOpt {
+	return func(s *functionSettings) {
+		s.requestDefaults = func() any { return defaults() }
+		s.requestDefaultsType = reflect.TypeFor[TReq]()
+	}
+}
+
+// checkRequestDefaults fails, when the [RequestDefaults] of a function are not of its request type
+func checkRequestDefaults(fns []Function) error {
+	for _, fn := range fns {
+		t := getFuncSettings(fn).requestDefaultsType
+		if t == nil || isVoid(fn.Req()) {
+			continue
+		}
+		if req := reflect.TypeOf(fn.Req()); t != req {
+			return fmt.Errorf("%s: the request defaults of the type %s are not of the request type %s", fn.Path(), t, req)
+		}
+	}
+	return nil
+}
+
+// Defaulter is implemented by request types, that set their default values, before the request is decoded onto them.
+// See [RequestDefaults].
+type Defaulter interface {
+	Defaults()
+}
+
+// Aliases mounts the function at the provided additional `paths`, e.g. to keep old paths working after a rename.
+// The aliases are not advertised in the spec and [Function.Name] and [Function.Module] are still derived from the canonical path.
+func Aliases(paths ...string) FuncOpt {
+	return func(s *functionSettings) {
+		s.aliases = append(s.aliases, paths...)
+	}
+}
+
+// RequireSecurity documents, that the function requires the security scheme `name` (see [WithSecurityScheme]).
+// When provided multiple times, each requirement is an alternative. Functions without requirements are public.
+// The requirement is only documented in the spec, enforcing it is up to a [Middleware].
+func RequireSecurity(name string, scopes ...string) FuncOpt {
+	return func(s *functionSettings) {
+		s.security = append(s.security, openapi3.NewSecurityRequirement().Authenticate(name, scopes...))
+	}
+}
+
+// ResponseHeader documents the header `name` of the successful response. The `schema` describes its value, e.g. [openapi3.NewIntegerSchema].
+// The function sets the header with [SetResponseHeader].
+func ResponseHeader(name, description string, schema *openapi3.Schema) FuncOpt {
+	return func(s *functionSettings) {
+		s.headers = append(s.headers, responseHeader{name, description, schema})
+	}
+}
+
+// MaxBodyBytes limits the size of the request body to `n` bytes, overriding the limit of the handler (see [WithMaxBodyBytes]),
+// e.g. for functions that accept large uploads.
+func MaxBodyBytes(n int64) FuncOpt {
+	return func(s *functionSettings) {
+		s.maxBodyBytes = n
+	}
+}
+
+// WithTags tags the operation of the function with `tags` instead of its [Function.Module], e.g. to group it in the swagger UI.
+// Tags can be described with [WithTagDescription].
+func WithTags(tags ...string) FuncOpt {
+	return func(s *functionSettings) {
+		s.tags = append(s.tags, tags...)
+	}
+}
+
+// WithExtension adds the vendor extension `key` (e.g. 'x-owner') to the operation of the function.
+// The `value` must be JSON serializable. Keys must start with 'x-'.
+func WithExtension(key string, value any) FuncOpt {
+	return func(s *functionSettings) {
+		if s.extensions == nil {
+			s.extensions = map[string]any{}
+		}
+		s.extensions[key] = value
+	}
+}
+
+// WithCircuitBreaker guards the function with the provided [CircuitBreaker].
+// While the circuit is open, calls are rejected with a [CircuitOpenError]
+// and the handler responds with 503 Service Unavailable.
+func WithCircuitBreaker(cb *CircuitBreaker) FuncOpt {
+	return func(s *functionSettings) {
+		s.breaker = cb
+	}
+}
+
+// ErrorResponse documents, that the function responds with `status` in the described case, e.g. 404 when an entity is not found.
+// The response uses the error schema of the handler (see [WithErrorSchema]).
+func ErrorResponse(status int, description string) FuncOpt {
+	return func(s *functionSettings) {
+		s.errorResponses = append(s.errorResponses, errorResponse{status, description})
+	}
+}
+
 type FuncOpt func(s *functionSettings)
 
+// settingsProvider is implemented by the functions created with [Func] and its variants.
+// It grants the [Handler] access to the [FuncOpt]s of a function.
+type settingsProvider interface {
+	funcSettings() functionSettings
+}
+
+// getFuncSettings returns the settings of `fn` or the default settings, when `fn` does not provide any.
+func getFuncSettings(fn Function) functionSettings {
+	if p, ok := fn.(settingsProvider); ok {
+		return p.funcSettings()
+	}
+	return functionSettings{}
+}
+
 // functionDefinition is an instance of [Function]
 type functionDefinition[TReq any, TRes any] struct {
 	name     string
@@ -135,34 +330,96 @@ func (def *functionDefinition[TReq, TRes]) Name() string {
 }
 
 func (def *functionDefinition[TReq, TRes]) Module() string {
-	i := strings.LastIndex(def.path, "/")
-	return strings.TrimPrefix(strings.ReplaceAll(def.path[:i], "/", "."), ".")
+	return moduleOf(def.path)
+}
+
+// moduleOf derives the module from the path of a function, e.g. '/geo/track' is in the module 'geo'
+func moduleOf(path string) string {
+	i := strings.LastIndex(path, "/")
+	return strings.TrimPrefix(strings.ReplaceAll(path[:i], "/", "."), ".")
 }
 
 func (def *functionDefinition[TReq, TRes]) Path() string {
 	return def.path
 }
 
+func (def *functionDefinition[TReq, TRes]) funcSettings() functionSettings {
+	return def.settings
+}
+
 func (def *functionDefinition[TReq, TRes]) Apply(ctx context.Context, dec Decoder, spec openapi3.T) (any, error) {
 	var req TReq
 	var res TRes
 
-	if _, ok := def.Req().(Void); ok {
-		return def.fn(ctx, req)
+	if isVoid(def.Req()) {
+		return def.call(ctx, req)
 	}
-	if err := dec.Decode(&req); err != nil {
-		return res, err
+	// pointer requests are allocated, so that an empty body is decoded as the zero value of the pointed type instead of nil
+	if t := reflect.TypeFor[TReq](); t.Kind() == reflect.Pointer {
+		req = reflect.New(t.Elem()).Interface().(TReq)
+	}
+	if defaults := def.settings.requestDefaults; defaults != nil {
+		v, ok := defaults().(TReq)
+		if !ok {
+			return res, fmt.Errorf("the request defaults of %s are not of the request type %T", def.Path(), req)
+		}
+		if ptr := reflect.ValueOf(v); reflect.TypeFor[TReq]().Kind() == reflect.Pointer {
+			// the defaults can be shared, so the request is decoded onto a copy
+			if !ptr.IsNil() {
+				reflect.ValueOf(req).Elem().Set(ptr.Elem())
+			}
+		} else {
+			req = v
+		}
+	} else if defaulter, ok := any(&req).(Defaulter); ok {
+		defaulter.Defaults()
+	} else if defaulter, ok := any(req).(Defaulter); ok {
+		// pointer requests implement the defaulter themselves
+		defaulter.Defaults()
 	}
 
-	if def.settings.validate {
-		ref := spec.Paths.Find(def.Path()).Post.RequestBody.Value.Content.Get("application/json").Schema.Ref
-		ref = strings.TrimPrefix(ref, "#/components/schemas/")
-		if err := spec.Components.Schemas[ref].Value.VisitJSON(req, openapi3.EnableFormatValidation()); err != nil {
+	// an empty body is the request with its defaults
+	if err := dec.Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("%w: %w", ErrDecode, err)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return res, SetErrStatus(err, http.StatusRequestEntityTooLarge)
+		}
+		// decoders can reject the request with their own status
+		if _, ok := GetErrStatus(err); ok {
 			return res, err
 		}
+		return res, SetErrStatus(err, http.StatusBadRequest)
+	}
+
+	if def.settings.validate && !def.settings.protoBody {
+		ref := requestSchema(spec, def, requestMediaType(req))
+		if err := validateJSON(spec, ref, req, fieldNamerFromContext(ctx)); err != nil {
+			return res, SetErrStatus(err, http.StatusBadRequest)
+		}
+	}
+
+	return def.call(ctx, req)
+}
+
+func (def *functionDefinition[TReq, TRes]) rebase(prefix string) Function {
+	rebased := *def
+	rebased.path = prefix + def.path
+	rebased.settings.aliases = nil
+	for _, alias := range def.settings.aliases {
+		rebased.settings.aliases = append(rebased.settings.aliases, prefix+alias)
 	}
+	return &rebased
+}
 
-	return def.fn(ctx, req)
+// call invokes the function, guarded by the circuit breaker if there is one
+func (def *functionDefinition[TReq, TRes]) call(ctx context.Context, req TReq) (any, error) {
+	if def.settings.breaker == nil {
+		return def.fn(ctx, req)
+	}
+	return def.settings.breaker.call(ctx, func(ctx context.Context) (any, error) {
+		return def.fn(ctx, req)
+	})
 }
 
 func (def *functionDefinition[TReq, TRes]) Req() any {
@@ -0,0 +1,60 @@
+// Package exposetest provides helpers for testing extensions of expose, e.g. custom encodings.
+package exposetest
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/pbedat/expose"
+)
+
+// TestEncoding asserts, that `enc` round-trips each of the `samples`: the sample is encoded and decoded into a new value
+// of its type, which must equal the sample. It also checks, that the encoding has a `MimeType`, an encoder and a decoder.
+// Encodings with GetDecoderWithRequest decode a request with the `Content-Type` of the encoding.
+func TestEncoding(t testing.TB, enc expose.Encoding, samples ...any) {
+	t.Helper()
+
+	if enc.MimeType == "" {
+		t.Errorf("the encoding has no MimeType")
+	}
+	if enc.GetEncoder == nil {
+		t.Fatalf("the encoding %q has no encoder", enc.MimeType)
+	}
+	if enc.GetDecoder == nil && enc.GetDecoderWithRequest == nil {
+		t.Fatalf("the encoding %q has no decoder", enc.MimeType)
+	}
+
+	for i, sample := range samples {
+		if sample == nil {
+			t.Errorf("sample %d: nil can not be decoded", i)
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := enc.GetEncoder(&buf).Encode(sample); err != nil {
+			t.Errorf("sample %d: failed to encode %#v: %s", i, sample, err)
+			continue
+		}
+		data := buf.Bytes()
+
+		var dec expose.Decoder
+		if enc.GetDecoderWithRequest != nil {
+			r := httptest.NewRequest("POST", "/", bytes.NewReader(data))
+			r.Header.Set("Content-Type", enc.MimeType)
+			dec = enc.GetDecoderWithRequest(r)
+		} else {
+			dec = enc.GetDecoder(bytes.NewReader(data))
+		}
+
+		decoded := reflect.New(reflect.TypeOf(sample))
+		if err := dec.Decode(decoded.Interface()); err != nil {
+			t.Errorf("sample %d: failed to decode %q: %s", i, data, err)
+			continue
+		}
+		if !reflect.DeepEqual(decoded.Elem().Interface(), sample) {
+			t.Errorf("sample %d: decoded %#v from %q, expected %#v", i, decoded.Elem().Interface(), data, sample)
+		}
+	}
+}
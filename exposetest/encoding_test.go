@@ -0,0 +1,80 @@
+package exposetest
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/pbedat/expose"
+	"github.com/ysmood/got"
+)
+
+// recorder records the failures of a test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	panic(r)
+}
+
+func record(enc expose.Encoding, samples ...any) (failures []string) {
+	r := &recorder{}
+	defer func() {
+		if v := recover(); v != nil && v != r {
+			panic(v)
+		}
+		failures = r.failures
+	}()
+	TestEncoding(r, enc, samples...)
+	return
+}
+
+type person struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestTestEncoding(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		TestEncoding(t, expose.JsonEncoding, "hello", 42, true, []string{"a", "b"}, map[string]int{"a": 1}, person{Name: "Ada", Age: 36})
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		g := got.T(t)
+		// the encoder drops the age
+		lossy := expose.JsonEncoding
+		lossy.GetEncoder = func(w io.Writer) expose.Encoder {
+			return expose.EncoderFunc(func(v any) error {
+				p := v.(person)
+				p.Age = 0
+				return expose.JsonEncoding.GetEncoder(w).Encode(p)
+			})
+		}
+
+		failures := record(lossy, person{Name: "Ada", Age: 36})
+		g.Len(failures, 1)
+		g.Has(failures[0], "sample 0: decoded")
+	})
+
+	t.Run("encode error", func(t *testing.T) {
+		g := got.T(t)
+		failures := record(expose.JsonEncoding, func() {})
+		g.Len(failures, 1)
+		g.Has(failures[0], "failed to encode")
+	})
+
+	t.Run("incomplete encoding", func(t *testing.T) {
+		g := got.T(t)
+		failures := record(expose.Encoding{GetEncoder: expose.JsonEncoding.GetEncoder}, 1)
+		g.Eq(failures, []string{"the encoding has no MimeType", `the encoding "" has no decoder`})
+	})
+}
@@ -0,0 +1,475 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// CamelCase transforms a Go field name into camel case, e.g. 'FirstName' becomes 'firstName' and 'HTTPServer' becomes 'httpServer'.
+// See [WithFieldNames].
+func CamelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		// the last upper case letter of an initialism starts the next word, e.g. the 'S' of 'HTTPServer'
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// fieldNamer renames the struct fields without a `json` name tag (see [WithFieldNames])
+// and converts 64 bit integers to strings (see [WithInt64AsString]) on the wire
+type fieldNamer struct {
+	transform func(name string) string
+	// int64Strings encodes int64 and uint64 values as strings
+	int64Strings bool
+	// fields caches the [jsonField]s of struct types
+	fields sync.Map
+}
+
+// newFieldNamer creates the namer of the wire format. It is nil, when the wire format is the go json representation.
+func newFieldNamer(transform func(name string) string, int64Strings bool) *fieldNamer {
+	if transform == nil && !int64Strings {
+		return nil
+	}
+	return &fieldNamer{transform: transform, int64Strings: int64Strings}
+}
+
+// jsonField is a struct field with its names in the go json representation and on the wire
+type jsonField struct {
+	index []int
+	typ   reflect.Type
+	name  string
+	wire  string
+}
+
+// structFields returns the json fields of the struct `t`, including the promoted fields of embedded structs
+func (n *fieldNamer) structFields(t reflect.Type) []jsonField {
+	if fields, ok := n.fields.Load(t); ok {
+		return fields.([]jsonField)
+	}
+
+	var fields []jsonField
+	seen := map[string]bool{}
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		var embedded []reflect.StructField
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			f.Index = append(append([]int{}, index...), i)
+			alias, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if alias == "-" || (!f.IsExported() && !f.Anonymous) {
+				continue
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && alias == "" && ft.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			name := n.name(f)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			fields = append(fields, jsonField{index: f.Index, typ: f.Type, name: jsonFieldName(f), wire: name})
+		}
+		// promoted fields are shadowed by the fields of the outer struct
+		for _, f := range embedded {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			collect(ft, f.Index)
+		}
+	}
+	collect(t, nil)
+
+	n.fields.Store(t, fields)
+	return fields
+}
+
+// name returns the name of the field `f` on the wire: the alias of its `json` tag or its transformed name
+func (n *fieldNamer) name(f reflect.StructField) string {
+	if n == nil || n.transform == nil {
+		return jsonFieldName(f)
+	}
+	if alias, _, _ := strings.Cut(f.Tag.Get("json"), ","); alias != "" {
+		return alias
+	}
+	return n.transform(f.Name)
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	name, _ := jsonName(f)
+	return name
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// customJSON reports whether `t` defines its own json representation, whose fields are not renamed
+func customJSON(t reflect.Type) bool {
+	for _, iface := range []reflect.Type{jsonMarshalerType, jsonUnmarshalerType, textMarshalerType} {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// marshal encodes `v` as JSON with the field names on the wire.
+// HTML is not escaped, the encoding, that writes the JSON, escapes it, unless it is disabled (see [JSONEscapeHTML]).
+func (n *fieldNamer) marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	data := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if n == nil {
+		return data, nil
+	}
+	return n.rename(reflect.ValueOf(v), reflect.TypeOf(v), data, true)
+}
+
+// unmarshal decodes the JSON `data` with the field names on the wire into `v`
+func (n *fieldNamer) unmarshal(data []byte, v any) error {
+	t := reflect.TypeOf(v)
+	renamed, err := n.rename(reflect.Value{}, t, data, false)
+	if err != nil {
+		return err
+	}
+	err = jsonDecodeError(json.Unmarshal(renamed, v))
+
+	// the field error names the go json path of the field
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		fieldErr.Field = n.wirePath(t, fieldErr.Field)
+	}
+	return err
+}
+
+// rename renames the fields of the JSON `data` of the type `t` between their go json names and their names on the wire.
+// The value `v` of the data resolves the dynamic types of interfaces, it is invalid while decoding.
+func (n *fieldNamer) rename(v reflect.Value, t reflect.Type, data []byte, toWire bool) ([]byte, error) {
+	if v.IsValid() && v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return data, nil
+		}
+		v = v.Elem()
+		t = v.Type()
+	}
+	if t == nil || customJSON(t) {
+		return data, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsValid() {
+			if v.IsNil() {
+				return data, nil
+			}
+			v = v.Elem()
+		}
+		return n.rename(v, t.Elem(), data, toWire)
+	case reflect.Slice, reflect.Array:
+		i := 0
+		return mapJSONArray(data, func(elem []byte) ([]byte, error) {
+			var ev reflect.Value
+			if v.IsValid() && i < v.Len() {
+				ev = v.Index(i)
+			}
+			i++
+			return n.rename(ev, t.Elem(), elem, toWire)
+		})
+	case reflect.Map:
+		return mapJSONObject(data, func(key string, value []byte) (string, []byte, error) {
+			var ev reflect.Value
+			if v.IsValid() && t.Key().Kind() == reflect.String {
+				ev = v.MapIndex(reflect.ValueOf(key).Convert(t.Key()))
+			}
+			value, err := n.rename(ev, t.Elem(), value, toWire)
+			return key, value, err
+		})
+	case reflect.Struct:
+		fields := map[string]jsonField{}
+		for _, f := range n.structFields(t) {
+			if toWire {
+				fields[f.name] = f
+			} else {
+				fields[f.wire] = f
+			}
+		}
+		return mapJSONObject(data, func(key string, value []byte) (string, []byte, error) {
+			f, ok := fields[key]
+			if !ok {
+				return key, value, nil
+			}
+			var fv reflect.Value
+			if v.IsValid() {
+				// the field of a nil embedded pointer is not set
+				fv, _ = v.FieldByIndexErr(f.index)
+			}
+			value, err := n.rename(fv, f.typ, value, toWire)
+			if toWire {
+				return f.wire, value, err
+			}
+			return f.name, value, err
+		})
+	case reflect.Int64, reflect.Uint64:
+		if n.int64Strings {
+			return convertInt64(data, toWire), nil
+		}
+		return data, nil
+	default:
+		return data, nil
+	}
+}
+
+// convertInt64 quotes the JSON number `data` on the way to the wire and unquotes JSON strings of integers on the way back.
+// Numbers are accepted on the wire as well. Other values are returned as is, so that they fail to decode.
+func convertInt64(data []byte, toWire bool) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if toWire {
+		if firstJSONByte(trimmed) == '"' || bytes.Equal(trimmed, []byte("null")) {
+			return data
+		}
+		return append(append([]byte{'"'}, trimmed...), '"')
+	}
+	var s string
+	if firstJSONByte(trimmed) != '"' || json.Unmarshal(trimmed, &s) != nil {
+		return data
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+		if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+			return data
+		}
+	}
+	return []byte(s)
+}
+
+// wirePath translates the dot separated go json `path` of a field in `t` into the names on the wire
+func (n *fieldNamer) wirePath(t reflect.Type, path string) string {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || customJSON(t) {
+			break
+		}
+		var next reflect.Type
+		for _, f := range n.structFields(t) {
+			if f.name == segment {
+				segments[i], next = f.wire, f.typ
+				break
+			}
+		}
+		t = next
+	}
+	return strings.Join(segments, ".")
+}
+
+// renameProperties renames the properties of struct schemas with the `namer`, like their fields on the wire
+func renameProperties(namer *fieldNamer) customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if namer == nil || t.Kind() != reflect.Struct || customJSON(t) {
+			return
+		}
+
+		for _, f := range namer.structFields(t) {
+			if f.name == f.wire {
+				continue
+			}
+			if prop, ok := schema.Properties[f.name]; ok {
+				delete(schema.Properties, f.name)
+				schema.Properties[f.wire] = prop
+			}
+			for i, required := range schema.Required {
+				if required == f.name {
+					schema.Required[i] = f.wire
+				}
+			}
+		}
+		return
+	}
+}
+
+// withFieldNames encodes and decodes the values of `enc` in the wire format of the `namer`, see [WithFieldNames] and [WithInt64AsString]
+func withFieldNames(enc Encoding, namer *fieldNamer) Encoding {
+	renamed := enc
+	if enc.GetEncoder != nil {
+		renamed.GetEncoder = func(w io.Writer) Encoder {
+			encoder := enc.GetEncoder(w)
+			return EncoderFunc(func(v any) error {
+				data, err := namer.marshal(v)
+				if err != nil {
+					return err
+				}
+				return encoder.Encode(json.RawMessage(data))
+			})
+		}
+	}
+	renamedDecoder := func(dec Decoder) Decoder {
+		return DecoderFunc(func(v any) error {
+			var data json.RawMessage
+			if err := dec.Decode(&data); err != nil {
+				return err
+			}
+			return namer.unmarshal(data, v)
+		})
+	}
+	if enc.GetDecoder != nil {
+		renamed.GetDecoder = func(r io.Reader) Decoder {
+			return renamedDecoder(enc.GetDecoder(r))
+		}
+	}
+	if enc.GetDecoderWithRequest != nil {
+		renamed.GetDecoderWithRequest = func(r *http.Request) Decoder {
+			return renamedDecoder(enc.GetDecoderWithRequest(r))
+		}
+	}
+	return renamed
+}
+
+// WireEncoding returns the JSON encoding of a handler with the `options`, which encodes the values in the wire format of the handler
+// (see [WithFieldNames] and [WithInt64AsString]). Use it in clients, that encode the Go types of the functions, e.g. in the client
+// generated by [GenerateGoClient].
+func WireEncoding(options ...HandlerOption) Encoding {
+	settings := newHandlerSettings(options...)
+	if settings.fieldNames == nil {
+		return JsonEncoding
+	}
+	return withFieldNames(JsonEncoding, settings.fieldNames)
+}
+
+// isJSONMimeType reports whether values of the `mimeType` are JSON encoded, like 'application/json' or 'application/problem+json'
+func isJSONMimeType(mimeType string) bool {
+	return mimeType == "application/json" || strings.HasSuffix(mimeType, "+json")
+}
+
+type fieldNamerKey struct{}
+
+// withFieldNamer passes the `namer` of the handler to the validation of the request, see [Function.Apply]
+func withFieldNamer(ctx context.Context, namer *fieldNamer) context.Context {
+	if namer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, fieldNamerKey{}, namer)
+}
+
+func fieldNamerFromContext(ctx context.Context) *fieldNamer {
+	namer, _ := ctx.Value(fieldNamerKey{}).(*fieldNamer)
+	return namer
+}
+
+// mapJSONObject calls `fn` with every member of the JSON object `data` and returns the object of the mapped members.
+// The order of the members is preserved. Any other JSON value is returned as is.
+func mapJSONObject(data []byte, fn func(key string, value []byte) (string, []byte, error)) ([]byte, error) {
+	if firstJSONByte(data) != '{' {
+		return data, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		key, value, err = fn(key, value)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// mapJSONArray calls `fn` with every element of the JSON array `data` and returns the array of the mapped elements.
+// Any other JSON value is returned as is.
+func mapJSONArray(data []byte, fn func(elem []byte) ([]byte, error)) ([]byte, error) {
+	if firstJSONByte(data) != '[' {
+		return data, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for dec.More() {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return nil, err
+		}
+		elem, err := fn(elem)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(elem)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+func firstJSONByte(data []byte) byte {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
@@ -0,0 +1,195 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"FirstName":  "firstName",
+		"ID":         "id",
+		"UserID":     "userID",
+		"HTTPServer": "httpServer",
+		"A":          "a",
+		"already":    "already",
+		"":           "",
+	}
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			got.T(t).Eq(CamelCase(name), expected)
+		})
+	}
+}
+
+type namedAddress struct {
+	StreetName string
+	Zip        string `json:"postal_code"`
+}
+
+type namedAudit struct {
+	CreatedBy string
+}
+
+type namedPerson struct {
+	namedAudit
+	FirstName string
+	Age       int
+	Nickname  *string
+	Address   namedAddress
+	Tags      map[string]namedAddress
+	Extra     any
+	Ignored   string `json:"-"`
+}
+
+func TestFieldNames(t *testing.T) {
+	var received namedPerson
+	fns := []Function{
+		Func("/greet", func(ctx context.Context, req namedPerson) (namedPerson, error) {
+			received = req
+			req.Extra = namedAddress{StreetName: "dynamic"}
+			return req, nil
+		}, Validate(true)),
+	}
+	h, err := NewHandler(fns, WithFieldNames(CamelCase), WithResponseValidation(true))
+	got.T(t).Must().Nil(err)
+
+	t.Run("wire format", func(t *testing.T) {
+		g := got.T(t)
+		body := `{"createdBy":"admin","firstName":"Ada","age":36,"nickname":null,"address":{"streetName":"Main","postal_code":"1234"},"tags":{"home":{"streetName":"Side","postal_code":"4321"}},"extra":"static"}`
+		r := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(body))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		// the request and the response are validated with the transformed names
+		g.Must().Eq(w.Code, http.StatusOK)
+		g.Eq(received.CreatedBy, "admin")
+		g.Eq(received.FirstName, "Ada")
+		g.Eq(received.Address, namedAddress{StreetName: "Main", Zip: "1234"})
+		g.Eq(received.Tags["home"].StreetName, "Side")
+
+		// the fields keep their order, the promoted field and the dynamic value of the interface are renamed as well
+		g.Eq(w.Body.String(), `{"createdBy":"admin","firstName":"Ada","age":36,"nickname":null,"address":{"streetName":"Main","postal_code":"1234"},`+
+			`"tags":{"home":{"streetName":"Side","postal_code":"4321"}},"extra":{"streetName":"dynamic","postal_code":""}}`+"\n")
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		data, err := json.Marshal(&spec)
+		g.Must().Nil(err)
+		g.False(strings.Contains(string(data), `"FirstName"`))
+
+		person := spec.Components.Schemas["github.com.pbedat.expose.namedPerson"].Value
+		g.NotNil(person.Properties["firstName"])
+		g.NotNil(person.Properties["createdBy"])
+		g.NotNil(person.Properties["nickname"])
+		g.Nil(person.Properties["Ignored"])
+		g.Has(person.Required, "firstName")
+		g.Has(person.Required, "createdBy")
+
+		address := spec.Components.Schemas["github.com.pbedat.expose.namedAddress"].Value
+		g.NotNil(address.Properties["streetName"])
+		g.NotNil(address.Properties["postal_code"])
+	})
+
+	t.Run("escaping of the encoding", func(t *testing.T) {
+		g := got.T(t)
+		fns := []Function{
+			FuncNullary("/html", func(ctx context.Context) (namedAddress, error) {
+				return namedAddress{StreetName: "<b>"}, nil
+			}),
+		}
+		serve := func(opts ...HandlerOption) string {
+			h, err := NewHandler(fns, append(opts, WithFieldNames(CamelCase))...)
+			g.Must().Nil(err)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/html", nil))
+			return strings.TrimSpace(w.Body.String())
+		}
+
+		g.Eq(serve(), `{"streetName":"\u003cb\u003e","postal_code":""}`)
+		g.Eq(serve(WithEncodings(NewJSONEncoding(JSONEscapeHTML(false)))), `{"streetName":"<b>","postal_code":""}`)
+	})
+
+	t.Run("decode error names the wire field", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(`{"address":{"streetName":1}}`))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusBadRequest)
+		g.Has(w.Body.String(), "field `address.streetName` expected string, got number")
+	})
+}
+
+type int64Order struct {
+	ID       int64            `json:"id"`
+	Total    uint64           `json:"total"`
+	Parent   *int64           `json:"parent"`
+	Items    []int64          `json:"items"`
+	Quantity int              `json:"quantity"`
+	Counts   map[string]int64 `json:"counts"`
+}
+
+func TestInt64AsString(t *testing.T) {
+	var received int64Order
+	fns := []Function{
+		Func("/orders/save", func(ctx context.Context, order int64Order) (int64Order, error) {
+			received = order
+			return order, nil
+		}, Validate(true)),
+	}
+	h, err := NewHandler(fns, WithInt64AsString(true), WithResponseValidation(true))
+	got.T(t).Must().Nil(err)
+
+	call := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/orders/save", strings.NewReader(body))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("wire format", func(t *testing.T) {
+		g := got.T(t)
+		w := call(`{"id":"9007199254740993","total":"18446744073709551615","parent":null,"items":["1",2],"quantity":3,"counts":{"a":"4"}}`)
+		g.Must().Eq(w.Code, http.StatusOK)
+		g.Eq(received.ID, int64(9007199254740993))
+		g.Eq(received.Total, uint64(18446744073709551615))
+		g.Eq(received.Items, []int64{1, 2})
+
+		g.Eq(w.Body.String(), `{"id":"9007199254740993","total":"18446744073709551615","parent":null,"items":["1","2"],"quantity":3,"counts":{"a":"4"}}`+"\n")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		g := got.T(t)
+		w := call(`{"id":"one"}`)
+		g.Eq(w.Code, http.StatusBadRequest)
+		g.Has(w.Body.String(), "field `id`")
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		order := spec.Components.Schemas["github.com.pbedat.expose.int64Order"].Value
+		g.Eq(order.Properties["id"].Value.Type.Slice(), []string{"string"})
+		g.Eq(order.Properties["id"].Value.Format, "int64")
+		g.Eq(order.Properties["total"].Value.Format, "uint64")
+		g.Eq(order.Properties["parent"].Value.Type.Slice(), []string{"string"})
+		g.True(order.Properties["parent"].Value.Nullable)
+		g.Eq(order.Properties["items"].Value.Items.Value.Type.Slice(), []string{"string"})
+		g.Eq(order.Properties["quantity"].Value.Type.Slice(), []string{"integer"})
+	})
+}
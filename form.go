@@ -0,0 +1,95 @@
+package expose
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// FormEncoding decodes 'application/x-www-form-urlencoded' requests, e.g. posted by HTML forms. Register it with [WithEncodings].
+//
+// The form values are decoded into the fields of the request struct, that match their names (case insensitive), their `form` tag,
+// e.g. `form:"email"`, or their `json` tag. Values are converted to the type of their field, e.g. "1" to an int or "on" (a checked checkbox) to true,
+// and repeated values fill slices.
+//
+// Responses are not form encoded: requests without an "Accept" header are answered with the default encoding (see [WithDefaultEncoding]).
+var FormEncoding = Encoding{
+	MimeType: "application/x-www-form-urlencoded",
+	GetDecoder: func(r io.Reader) Decoder {
+		return DecoderFunc(func(v any) error {
+			body, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if len(body) == 0 {
+				return io.EOF
+			}
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				return fmt.Errorf("invalid form: %w", err)
+			}
+			return decodeForm(values, v)
+		})
+	},
+}
+
+// decodeForm decodes the form `values` into `v`
+func decodeForm(values url.Values, v any) error {
+	return decodeFormMap(formMap(values), v)
+}
+
+// formMap maps the names of the form `values` to their value or to all values, when they are repeated
+func formMap(values map[string][]string) map[string]any {
+	m := make(map[string]any, len(values))
+	for name, vs := range values {
+		if len(vs) == 1 {
+			m[name] = vs[0]
+			continue
+		}
+		m[name] = vs
+	}
+	return m
+}
+
+// decodeFormMap decodes the form values `m` (see [formMap]) into `v`.
+// Fields without a `form` tag are named by their `json` tag.
+func decodeFormMap(m map[string]any, v any) error {
+	if t := reflect.TypeOf(v); t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct {
+		for _, f := range reflect.VisibleFields(t.Elem()) {
+			if _, ok := f.Tag.Lookup("form"); ok || !f.IsExported() {
+				continue
+			}
+			if name, _ := jsonName(f); name != "-" && name != f.Name {
+				if value, ok := m[name]; ok {
+					delete(m, name)
+					m[f.Name] = value
+				}
+			}
+		}
+	}
+
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "form",
+		WeaklyTypedInput: true,
+		DecodeHook:       decodeCheckbox,
+		Result:           v,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return fmt.Errorf("invalid form: %w", err)
+	}
+	return nil
+}
+
+// decodeCheckbox decodes the value "on", that a checked checkbox submits, as true
+func decodeCheckbox(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if s, ok := data.(string); ok && to.Kind() == reflect.Bool && s == "on" {
+		return true, nil
+	}
+	return data, nil
+}
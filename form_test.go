@@ -0,0 +1,76 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestFormEncoding(t *testing.T) {
+	type signup struct {
+		Email      string `form:"email"`
+		Age        int
+		Interests  []string `form:"interest"`
+		Newsletter bool     `form:"newsletter"`
+		Country    string   `json:"country"`
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/users/signup", func(ctx context.Context, req signup) (signup, error) {
+			return req, nil
+		}),
+	}, WithEncodings(FormEncoding))
+	got.T(t).Must().Nil(err)
+
+	post := func(body string, accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/users/signup", strings.NewReader(body))
+		r.Header.Set("content-type", "application/x-www-form-urlencoded")
+		if accept != "" {
+			r.Header.Set("accept", accept)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("decodes form values", func(t *testing.T) {
+		g := got.T(t)
+		w := post("email=a%40example.com&age=42&interest=go&interest=rpc&newsletter=on&country=de", "")
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+
+		var res signup
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &res))
+		g.Eq(res, signup{Email: "a@example.com", Age: 42, Interests: []string{"go", "rpc"}, Newsletter: true, Country: "de"})
+	})
+
+	t.Run("single value fills a slice", func(t *testing.T) {
+		g := got.T(t)
+		w := post("interest=go", "application/json")
+
+		g.Eq(w.Code, http.StatusOK)
+		var res signup
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &res))
+		g.Eq(res.Interests, []string{"go"})
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		g := got.T(t)
+		w := post("age=old", "")
+
+		g.Eq(w.Code, http.StatusBadRequest)
+	})
+
+	t.Run("responses are not form encoded", func(t *testing.T) {
+		g := got.T(t)
+		w := post("age=1", "application/x-www-form-urlencoded")
+
+		g.Eq(w.Code, http.StatusBadRequest)
+	})
+}
@@ -1,7 +1,11 @@
 package exposefx
 
 import (
+	"fmt"
+	"sync/atomic"
+
 	"github.com/pbedat/expose"
+	"github.com/samber/lo"
 	"go.uber.org/fx"
 )
 
@@ -10,7 +14,34 @@ func ProvideRouter(ctor any) fx.Option {
 	return fx.Provide(fx.Annotate(ctor, fx.ResultTags(`group:"expose_routers"`), fx.As(new(Router))))
 }
 
+var routerCount atomic.Int64
+
+// ProvideRouterAt provides the `ctor` as [Router], whose functions are mounted under `prefix` (see [expose.Rebase]).
+// The paths of the functions are relative to the prefix, e.g. the function '/inc' of a router at '/counter' is exposed at '/counter/inc'.
+func ProvideRouterAt(prefix string, ctor any) fx.Option {
+	name := fmt.Sprintf(`name:"expose_router_%d"`, routerCount.Add(1))
+
+	return fx.Options(
+		fx.Provide(fx.Annotate(ctor, fx.ResultTags(name), fx.As(new(Router)))),
+		fx.Provide(fx.Annotate(func(r Router) Router {
+			return &prefixedRouter{prefix: prefix, router: r}
+		}, fx.ParamTags(name), fx.ResultTags(`group:"expose_routers"`))),
+	)
+}
+
 // Router can be implemented to colocate routes with their handlers
 type Router interface {
 	Expose() []expose.Function
 }
+
+// prefixedRouter mounts the functions of `router` under `prefix`
+type prefixedRouter struct {
+	prefix string
+	router Router
+}
+
+func (r *prefixedRouter) Expose() []expose.Function {
+	return lo.Map(r.router.Expose(), func(fn expose.Function, _ int) expose.Function {
+		return expose.Rebase(fn, r.prefix)
+	})
+}
@@ -0,0 +1,58 @@
+package exposefx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/pbedat/expose"
+	"github.com/samber/lo"
+	"github.com/ysmood/got"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type counterRouter struct {
+	count int
+}
+
+func (r *counterRouter) Expose() []expose.Function {
+	return []expose.Function{
+		expose.FuncNullary("/inc", func(ctx context.Context) (int, error) {
+			r.count++
+			return r.count, nil
+		}),
+	}
+}
+
+func TestProvideRouterAt(t *testing.T) {
+	g := got.T(t)
+
+	var h *expose.Handler
+	app := fxtest.New(t,
+		ProvideRouterAt("/counter", func() *counterRouter { return &counterRouter{} }),
+		ProvideRouterAt("/other", func() *counterRouter { return &counterRouter{count: 10} }),
+		ProvideRouter(func() *counterRouter { return &counterRouter{count: 100} }),
+		ProvideHandler(),
+		fx.Populate(&h),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	paths := lo.Map(h.Functions(), func(fn expose.Function, _ int) string { return fn.Path() })
+	slices.Sort(paths)
+	g.Eq(paths, []string{"/counter/inc", "/inc", "/other/inc"})
+
+	call := func(path string) string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		g.Eq(w.Code, http.StatusOK)
+		return w.Body.String()
+	}
+	g.Eq(call("/counter/inc"), "1\n")
+	g.Eq(call("/counter/inc"), "2\n")
+	g.Eq(call("/other/inc"), "11\n")
+	g.Eq(call("/inc"), "101\n")
+}
@@ -1,6 +1,9 @@
 package exposefx
 
 import (
+	"cmp"
+	"slices"
+
 	"github.com/pbedat/expose"
 	"github.com/samber/lo"
 	"go.uber.org/fx"
@@ -8,8 +11,11 @@ import (
 
 type HandlerParams struct {
 	fx.In
-	ExposedFunctions []expose.Function `group:"expose_functions"`
-	ExposedRouters   []Router          `group:"expose_routers"`
+	ExposedFunctions   []expose.Function   `group:"expose_functions"`
+	ExposedRouters     []Router            `group:"expose_routers"`
+	OrderedMiddlewares []OrderedMiddleware `group:"expose_middleware"`
+	// ScopedProviders create the request scoped values, see [ProvideScoped]
+	ScopedProviders []ScopedProvider `group:"expose_scoped"`
 }
 
 func (p HandlerParams) Functions() []expose.Function {
@@ -20,9 +26,31 @@ func (p HandlerParams) Functions() []expose.Function {
 	)
 }
 
-// ProvideHandler provides the expose handler
+// Middlewares returns the provided middlewares in their order
+func (p HandlerParams) Middlewares() []expose.Middleware {
+	ordered := slices.Clone(p.OrderedMiddlewares)
+	slices.SortStableFunc(ordered, func(a, b OrderedMiddleware) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
+	return lo.Map(ordered, func(m OrderedMiddleware, _ int) expose.Middleware {
+		return m.Middleware
+	})
+}
+
+// Options returns the handler options of the provided middlewares and scoped providers
+func (p HandlerParams) Options() []expose.HandlerOption {
+	opts := []expose.HandlerOption{expose.WithMiddleware(p.Middlewares()...)}
+	if len(p.ScopedProviders) > 0 {
+		opts = append(opts, expose.WithInterceptor(scopeInterceptor(p.ScopedProviders)))
+	}
+	return opts
+}
+
+// ProvideHandler provides the expose handler.
+// The provided middlewares (see [ProvideMiddleware]) are applied after the middlewares of `opts`.
+// Every call opens a request scope of the provided scoped values (see [ProvideScoped]), inside of the interceptors of `opts`.
 func ProvideHandler(opts ...expose.HandlerOption) fx.Option {
 	return fx.Provide(func(p HandlerParams) (*expose.Handler, error) {
-		return expose.NewHandler(p.Functions(), opts...)
+		return expose.NewHandler(p.Functions(), append(slices.Clone(opts), p.Options()...)...)
 	})
 }
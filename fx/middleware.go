@@ -0,0 +1,32 @@
+package exposefx
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/pbedat/expose"
+	"go.uber.org/fx"
+)
+
+// OrderedMiddleware is a middleware of the `expose_middleware` group.
+// The middlewares are applied to the handler in ascending order, see [expose.WithMiddleware].
+type OrderedMiddleware struct {
+	Order      int
+	Middleware expose.Middleware
+}
+
+var middlewareCount atomic.Int64
+
+// ProvideMiddleware provides the `ctor` of an [expose.Middleware], that is applied to the handler.
+// The middlewares are applied in the order, in which they are provided.
+func ProvideMiddleware(ctor any) fx.Option {
+	order := int(middlewareCount.Add(1))
+	name := fmt.Sprintf(`name:"expose_middleware_%d"`, order)
+
+	return fx.Options(
+		fx.Provide(fx.Annotate(ctor, fx.ResultTags(name))),
+		fx.Provide(fx.Annotate(func(mw expose.Middleware) OrderedMiddleware {
+			return OrderedMiddleware{Order: order, Middleware: mw}
+		}, fx.ParamTags(name), fx.ResultTags(`group:"expose_middleware"`))),
+	)
+}
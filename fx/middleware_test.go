@@ -0,0 +1,49 @@
+package exposefx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pbedat/expose"
+	"github.com/ysmood/got"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestProvideMiddleware(t *testing.T) {
+	g := got.T(t)
+
+	// tag appends `tag` to the X-Order header of the request and the response
+	tag := func(tag string) expose.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.Header.Add("X-Order", tag)
+				w.Header().Set("X-Order", strings.Join(r.Header.Values("X-Order"), ","))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	tagMiddleware := func(name string) func() expose.Middleware {
+		return func() expose.Middleware {
+			return tag(name)
+		}
+	}
+
+	var h *expose.Handler
+	app := fxtest.New(t,
+		ProvideMiddleware(tagMiddleware("first")),
+		ProvideMiddleware(tagMiddleware("second")),
+		ProvideMiddleware(tagMiddleware("third")),
+		ProvideHandler(expose.WithMiddleware(tag("opts"))),
+		fx.Populate(&h),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+	// the last middleware is the outermost (see [expose.WithMiddleware]) and the provided middlewares wrap the ones of the options
+	g.Eq(w.Header().Get("X-Order"), "third,second,first,opts")
+}
@@ -0,0 +1,159 @@
+package exposefx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pbedat/expose"
+	"go.uber.org/fx"
+)
+
+// Release ends a request scoped value after the call, e.g. commits or rolls back a transaction.
+// `err` is the error of the call. An error of the release fails the call.
+type Release func(err error) error
+
+// ScopeFunc creates the request scoped value of a call from the context of the call.
+// The returned [Release] is optional.
+type ScopeFunc[T any] func(ctx context.Context) (T, Release, error)
+
+// ScopedProvider creates request scoped values of a type, see [ProvideScoped]
+type ScopedProvider struct {
+	typ    reflect.Type
+	create func(ctx context.Context) (any, Release, error)
+}
+
+var scopedCount atomic.Int64
+
+// ProvideScoped provides the `ctor` of a [ScopeFunc], that creates a request scoped dependency of the type T,
+// e.g. a database transaction per call:
+//
+//	exposefx.ProvideScoped[*sql.Tx](func(db *sql.DB) exposefx.ScopeFunc[*sql.Tx] {
+//		return func(ctx context.Context) (*sql.Tx, exposefx.Release, error) {
+//			tx, err := db.BeginTx(ctx, nil)
+//			if err != nil {
+//				return nil, nil, err
+//			}
+//			return tx, func(err error) error {
+//				if err != nil {
+//					return tx.Rollback()
+//				}
+//				return tx.Commit()
+//			}, nil
+//		}
+//	})
+//
+// The `ctor` itself is resolved by fx once, so it can depend on the singletons of the app.
+// The handler of [ProvideHandler] opens a scope for every call of an exposed function (see [expose.WithInterceptor]).
+// The functions resolve the values of the scope from their context with [Resolve]. A value is created on its first resolution
+// and released, after the function returned and before the response is written. Values are released in the reverse order of their creation.
+func ProvideScoped[T any](ctor any) fx.Option {
+	name := fmt.Sprintf(`name:"expose_scoped_%d"`, scopedCount.Add(1))
+
+	return fx.Options(
+		fx.Provide(fx.Annotate(ctor, fx.ResultTags(name))),
+		fx.Provide(fx.Annotate(func(create ScopeFunc[T]) ScopedProvider {
+			return ScopedProvider{
+				typ: reflect.TypeFor[T](),
+				create: func(ctx context.Context) (any, Release, error) {
+					return create(ctx)
+				},
+			}
+		}, fx.ParamTags(name), fx.ResultTags(`group:"expose_scoped"`))),
+	)
+}
+
+// Resolve returns the request scoped value of the type T in the scope of the call, whose context is `ctx` (see [ProvideScoped]).
+// The value is created on the first resolution in the scope.
+func Resolve[T any](ctx context.Context) (T, error) {
+	var v T
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return v, errors.New("no request scope: resolve scoped values in the calls of exposed functions")
+	}
+
+	t := reflect.TypeFor[T]()
+	value, err := s.resolve(ctx, t)
+	if err != nil {
+		return v, fmt.Errorf("failed to resolve the scoped %s: %w", t, err)
+	}
+	return value.(T), nil
+}
+
+type scopeKey struct{}
+
+// scope holds the request scoped values of a call
+type scope struct {
+	providers map[reflect.Type]ScopedProvider
+
+	mu       sync.Mutex
+	values   map[reflect.Type]*scopedValue
+	releases []Release
+}
+
+type scopedValue struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+func (s *scope) resolve(ctx context.Context, t reflect.Type) (any, error) {
+	provider, ok := s.providers[t]
+	if !ok {
+		return nil, errors.New("no scoped provider")
+	}
+
+	s.mu.Lock()
+	entry, ok := s.values[t]
+	if !ok {
+		entry = &scopedValue{}
+		s.values[t] = entry
+	}
+	s.mu.Unlock()
+
+	// a provider can resolve other scoped values, so the scope is not locked while creating
+	entry.once.Do(func() {
+		var release Release
+		entry.value, release, entry.err = provider.create(ctx)
+		if entry.err == nil && release != nil {
+			s.mu.Lock()
+			s.releases = append(s.releases, release)
+			s.mu.Unlock()
+		}
+	})
+	return entry.value, entry.err
+}
+
+// release releases the created values in the reverse order of their creation
+func (s *scope) release(err error) error {
+	s.mu.Lock()
+	releases := slices.Clone(s.releases)
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(releases) - 1; i >= 0; i-- {
+		errs = append(errs, releases[i](err))
+	}
+	return errors.Join(errs...)
+}
+
+// scopeInterceptor opens a scope of the `providers` for every call
+func scopeInterceptor(providers []ScopedProvider) expose.Interceptor {
+	byType := map[reflect.Type]ScopedProvider{}
+	for _, p := range providers {
+		byType[p.typ] = p
+	}
+
+	return func(ctx context.Context, fn expose.Function, next func(ctx context.Context) (any, error)) (any, error) {
+		s := &scope{providers: byType, values: map[reflect.Type]*scopedValue{}}
+		res, err := next(context.WithValue(ctx, scopeKey{}, s))
+		if releaseErr := s.release(err); releaseErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to release the request scope of %s: %w", fn.Path(), releaseErr))
+		}
+		return res, err
+	}
+}
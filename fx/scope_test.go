@@ -0,0 +1,144 @@
+package exposefx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pbedat/expose"
+	"github.com/ysmood/got"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type scopedA struct{}
+type scopedB struct{ a *scopedA }
+type scopedC struct{}
+
+func TestProvideScoped(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	var releaseErr error
+	newHandler := func(t *testing.T, fn expose.Function) *expose.Handler {
+		mu.Lock()
+		events, releaseErr = nil, nil
+		mu.Unlock()
+
+		var h *expose.Handler
+		app := fxtest.New(t,
+			ProvideScoped[*scopedA](func() ScopeFunc[*scopedA] {
+				return func(ctx context.Context) (*scopedA, Release, error) {
+					record("create a")
+					return &scopedA{}, func(err error) error {
+						record("release a")
+						return nil
+					}, nil
+				}
+			}),
+			ProvideScoped[*scopedB](func() ScopeFunc[*scopedB] {
+				return func(ctx context.Context) (*scopedB, Release, error) {
+					a, err := Resolve[*scopedA](ctx)
+					if err != nil {
+						return nil, nil, err
+					}
+					record("create b")
+					return &scopedB{a: a}, func(err error) error {
+						record("release b")
+						return releaseErr
+					}, nil
+				}
+			}),
+			ProvideScoped[*scopedC](func() ScopeFunc[*scopedC] {
+				return func(ctx context.Context) (*scopedC, Release, error) {
+					record("create c")
+					return &scopedC{}, nil, nil
+				}
+			}),
+			ProvideFunc(fn),
+			ProvideHandler(),
+			fx.Populate(&h),
+		)
+		app.RequireStart()
+		t.Cleanup(app.RequireStop)
+		return h
+	}
+
+	useB := expose.FuncNullaryVoid("/use", func(ctx context.Context) error {
+		b, err := Resolve[*scopedB](ctx)
+		if err != nil {
+			return err
+		}
+		again, err := Resolve[*scopedB](ctx)
+		if err != nil {
+			return err
+		}
+		a, err := Resolve[*scopedA](ctx)
+		if err != nil {
+			return err
+		}
+		if b != again || b.a != a {
+			return errors.New("the scoped values differ in one scope")
+		}
+		record("call")
+		return nil
+	})
+
+	t.Run("lazy creation and release order", func(t *testing.T) {
+		g := got.T(t)
+		h := newHandler(t, useB)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/use", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(events, []string{"create a", "create b", "call", "release b", "release a"})
+
+		// every call opens a new scope
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/use", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Len(events, 10)
+	})
+
+	t.Run("release error fails the call", func(t *testing.T) {
+		g := got.T(t)
+		h := newHandler(t, useB)
+		releaseErr = errors.New("commit failed")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/use", nil))
+		g.Eq(w.Code, http.StatusInternalServerError)
+		g.Has(w.Body.String(), "failed to release the request scope of /use")
+		g.Has(w.Body.String(), "commit failed")
+		// the other values are released anyway
+		g.Eq(events, []string{"create a", "create b", "call", "release b", "release a"})
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		g := got.T(t)
+		h := newHandler(t, expose.FuncNullaryVoid("/unknown", func(ctx context.Context) error {
+			_, err := Resolve[string](ctx)
+			return err
+		}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/unknown", nil))
+		g.Eq(w.Code, http.StatusInternalServerError)
+		g.Has(w.Body.String(), "no scoped provider")
+		g.Len(events, 0)
+	})
+
+	t.Run("resolve outside of a scope", func(t *testing.T) {
+		g := got.T(t)
+		_, err := Resolve[*scopedA](context.Background())
+		g.Has(err.Error(), "no request scope")
+	})
+}
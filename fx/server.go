@@ -0,0 +1,38 @@
+package exposefx
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/pbedat/expose"
+	"go.uber.org/fx"
+)
+
+// ProvideServer provides a [http.Server] listening on `addr`, that serves the expose handler.
+// The server is started and gracefully shut down with the fx app.
+func ProvideServer(addr string) fx.Option {
+	return fx.Options(
+		fx.Provide(func(lc fx.Lifecycle, h *expose.Handler) *http.Server {
+			srv := &http.Server{Addr: addr, Handler: h}
+
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					// listen before returning, so that e.g. a taken port fails the start of the app
+					ln, err := net.Listen("tcp", srv.Addr)
+					if err != nil {
+						return err
+					}
+					go srv.Serve(ln)
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					return srv.Shutdown(ctx)
+				},
+			})
+
+			return srv
+		}),
+		fx.Invoke(func(*http.Server) {}),
+	)
+}
@@ -0,0 +1,61 @@
+package exposefx
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/pbedat/expose"
+	"github.com/ysmood/got"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// freeAddr returns an address on localhost, that is not taken
+func freeAddr(g got.G) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Must().Nil(err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestProvideServer(t *testing.T) {
+	ping := expose.FuncNullary("/ping", func(ctx context.Context) (string, error) {
+		return "pong", nil
+	})
+
+	t.Run("serves the handler with the app", func(t *testing.T) {
+		g := got.T(t)
+		addr := freeAddr(g)
+
+		var srv *http.Server
+		app := fxtest.New(t, ProvideFunc(ping), ProvideHandler(), ProvideServer(addr), fx.Populate(&srv))
+		g.Eq(srv.Addr, addr)
+
+		app.RequireStart()
+		res, err := http.Post("http://"+addr+"/ping", "application/json", nil)
+		g.Must().Nil(err)
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		g.Must().Nil(err)
+		g.Eq(res.StatusCode, http.StatusOK)
+		g.Eq(string(body), "\"pong\"\n")
+
+		app.RequireStop()
+		_, err = http.Post("http://"+addr+"/ping", "application/json", nil)
+		g.Err(err)
+	})
+
+	t.Run("taken address fails the start", func(t *testing.T) {
+		g := got.T(t)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		g.Must().Nil(err)
+		defer ln.Close()
+
+		app := fx.New(fx.NopLogger, ProvideFunc(ping), ProvideHandler(), ProvideServer(ln.Addr().String()))
+		g.Must().Nil(app.Err())
+		g.Err(app.Start(context.Background()))
+	})
+}
@@ -0,0 +1,324 @@
+package expose
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateGoClient writes the source of a Go client for the functions `fns` to `w`.
+// The client is placed in the package `pkg` and has one method per function, that uses
+// the actual request and response types of the function. The packages of these types are imported.
+//
+// The types of the functions must be expressible outside of their packages:
+// unexported named types, generic types, funcs and channels are not supported.
+//
+// The client encodes the values with their Go json names. When the handler changes the wire format (see [WithFieldNames]
+// and [WithInt64AsString]), set the `Encoding` of the client to the [WireEncoding] with the options of the handler.
+func GenerateGoClient(w io.Writer, pkg string, fns []Function) error {
+	fail := func(err error) error {
+		return fmt.Errorf("failed to generate go client: %w", err)
+	}
+
+	imports := goImports{
+		"bytes":                    "bytes",
+		"context":                  "context",
+		"io":                       "io",
+		"net/http":                 "http",
+		"github.com/pbedat/expose": "expose",
+	}
+
+	var methods bytes.Buffer
+	for _, fn := range fns {
+		if err := writeClientMethod(&methods, fn, imports); err != nil {
+			return fail(fmt.Errorf("%s: %w", fn.Path(), err))
+		}
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintln(&src, "// Code generated by expose. DO NOT EDIT.")
+	fmt.Fprintln(&src)
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	imports.write(&src)
+	src.WriteString(goClientTemplate)
+	src.Write(methods.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fail(err)
+	}
+
+	if _, err := w.Write(formatted); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+const goClientTemplate = `
+// Client calls the exposed functions of a remote handler
+type Client struct {
+	// BaseURL is the URL, that the function paths are relative to
+	BaseURL string
+	// HTTPClient performs the requests. Default: http.DefaultClient
+	HTTPClient *http.Client
+	// Encoding encodes the requests and decodes the responses. Default: expose.JsonEncoding
+	// Use the expose.WireEncoding of the handler, when it changes the wire format.
+	Encoding *expose.Encoding
+}
+
+// NewClient creates a [Client] for the handler at baseURL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+func (c *Client) call(ctx context.Context, path string, req any, res any) error {
+	enc := expose.JsonEncoding
+	if c.Encoding != nil {
+		enc = *c.Encoding
+	}
+
+	var body io.Reader = http.NoBody
+	if req != nil {
+		var buf bytes.Buffer
+		if err := enc.GetEncoder(&buf).Encode(req); err != nil {
+			return err
+		}
+		body = &buf
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("content-type", "application/json")
+	r.Header.Set("accept", "application/json")
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return expose.ErrorFromResponse(resp)
+	}
+	if res == nil {
+		return nil
+	}
+	return enc.GetDecoder(resp.Body).Decode(res)
+}
+`
+
+// writeClientMethod writes the client method, that calls `fn`
+func writeClientMethod(w io.Writer, fn Function, imports goImports) error {
+	if !slices.Contains(functionMethods(fn), http.MethodPost) {
+		return fmt.Errorf("the client only calls POST, but the function answers %s", strings.Join(functionMethods(fn), ", "))
+	}
+
+	nullary := isVoid(fn.Req())
+	void := isVoid(fn.Res())
+
+	var reqType, resType string
+	var err error
+	if !nullary {
+		if reqType, err = goTypeExpr(reflect.TypeOf(fn.Req()), imports); err != nil {
+			return fmt.Errorf("request: %w", err)
+		}
+	}
+	if !void {
+		if resType, err = goTypeExpr(reflect.TypeOf(fn.Res()), imports); err != nil {
+			return fmt.Errorf("response: %w", err)
+		}
+	}
+
+	name := goClientMethodName(fn.Path())
+	path := strconv.Quote(fn.Path())
+
+	fmt.Fprintf(w, "\n// %s calls %s\n", name, fn.Path())
+
+	params := "ctx context.Context"
+	reqArg := "nil"
+	if !nullary {
+		params += ", req " + reqType
+		reqArg = "req"
+	}
+
+	if void {
+		fmt.Fprintf(w, "func (c *Client) %s(%s) error {\n", name, params)
+		fmt.Fprintf(w, "\treturn c.call(ctx, %s, %s, nil)\n}\n", path, reqArg)
+		return nil
+	}
+
+	fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, error) {\n", name, params, resType)
+	fmt.Fprintf(w, "\tvar res %s\n", resType)
+	fmt.Fprintf(w, "\terr := c.call(ctx, %s, %s, &res)\n", path, reqArg)
+	fmt.Fprintf(w, "\treturn res, err\n}\n")
+	return nil
+}
+
+// goClientMethodName derives the method name from the path of a function, e.g. '/counter/inc' becomes 'CounterInc'
+func goClientMethodName(p string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range p {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Call" + name
+	}
+	return name
+}
+
+// goImports maps import paths to the names, they are referenced by
+type goImports map[string]string
+
+// name returns the name, that the package `pkgPath` is referenced by and registers the import.
+// `pkgName` is the actual name of the package.
+func (imports goImports) name(pkgPath, pkgName string) string {
+	if name, ok := imports[pkgPath]; ok {
+		return name
+	}
+
+	taken := map[string]bool{}
+	for _, name := range imports {
+		taken[name] = true
+	}
+
+	name := pkgName
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprint(pkgName, i)
+	}
+	imports[pkgPath] = name
+	return name
+}
+
+func (imports goImports) write(w io.Writer) {
+	paths := make([]string, 0, len(imports))
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		iStd, jStd := !strings.Contains(strings.Split(paths[i], "/")[0], "."), !strings.Contains(strings.Split(paths[j], "/")[0], ".")
+		if iStd != jStd {
+			return iStd
+		}
+		return paths[i] < paths[j]
+	})
+
+	fmt.Fprintln(w, "import (")
+	std := true
+	for _, p := range paths {
+		// the standard library is grouped before all other packages
+		if std && strings.Contains(strings.Split(p, "/")[0], ".") {
+			std = false
+			fmt.Fprintln(w)
+		}
+		if name := imports[p]; name != path.Base(p) {
+			fmt.Fprintf(w, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(w, "\t%q\n", p)
+		}
+	}
+	fmt.Fprintln(w, ")")
+}
+
+// goTypeExpr renders the go type expression of `t`. The packages of named types are added to the `imports`.
+func goTypeExpr(t reflect.Type, imports goImports) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			// predeclared type
+			return t.Name(), nil
+		}
+		if strings.Contains(t.Name(), "[") {
+			return "", fmt.Errorf("generic type %s is not supported", t)
+		}
+		if !isExported(t.Name()) {
+			return "", fmt.Errorf("unexported type %s is not supported", t)
+		}
+		pkgName, _, _ := strings.Cut(t.String(), ".")
+		return imports.name(t.PkgPath(), pkgName) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := goTypeExpr(t.Elem(), imports)
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := goTypeExpr(t.Elem(), imports)
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := goTypeExpr(t.Elem(), imports)
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := goTypeExpr(t.Key(), imports)
+		if err != nil {
+			return "", err
+		}
+		elem, err := goTypeExpr(t.Elem(), imports)
+		return fmt.Sprintf("map[%s]%s", key, elem), err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any", nil
+		}
+		return "", fmt.Errorf("interface type %s is not supported", t)
+	case reflect.Struct:
+		var sb strings.Builder
+		sb.WriteString("struct {")
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fieldType, err := goTypeExpr(f.Type, imports)
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			if f.Anonymous {
+				sb.WriteString(fieldType)
+			} else {
+				sb.WriteString(f.Name + " " + fieldType)
+			}
+			if f.Tag != "" && strings.Contains(string(f.Tag), "`") {
+				sb.WriteString(" " + strconv.Quote(string(f.Tag)))
+			} else if f.Tag != "" {
+				sb.WriteString(" `" + string(f.Tag) + "`")
+			}
+			sb.WriteString("; ")
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+func isExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+type GeoPoint struct {
+	Lat, Lng float64
+	At       time.Time
+	Source   *url.URL `json:"source,omitempty"`
+}
+
+func TestGenerateGoClient(t *testing.T) {
+	g := got.T(t)
+
+	var buf bytes.Buffer
+	err := GenerateGoClient(&buf, "geoclient", []Function{
+		Func("/geo/track", func(ctx context.Context, p GeoPoint) ([]GeoPoint, error) {
+			return nil, nil
+		}),
+		FuncNullary("/geo/stats", func(ctx context.Context) (map[string]int, error) {
+			return nil, nil
+		}),
+		FuncVoid("/geo/label", func(ctx context.Context, l struct {
+			Name string `json:"name"`
+		}) error {
+			return nil
+		}),
+		FuncNullaryVoid("/geo/reset", func(ctx context.Context) error {
+			return nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	src := buf.String()
+
+	f, err := parser.ParseFile(token.NewFileSet(), "client.go", src, parser.ImportsOnly)
+	g.Must().Nil(err)
+	g.Eq(f.Name.Name, "geoclient")
+
+	var imports []string
+	for _, imp := range f.Imports {
+		imports = append(imports, imp.Path.Value)
+	}
+	g.Eq(imports, []string{
+		`"bytes"`, `"context"`, `"io"`, `"net/http"`,
+		`"github.com/pbedat/expose"`,
+	})
+
+	g.Has(src, "func (c *Client) GeoTrack(ctx context.Context, req expose.GeoPoint) ([]expose.GeoPoint, error) {")
+	g.Has(src, "func (c *Client) GeoStats(ctx context.Context) (map[string]int, error) {")
+	g.Has(src, "func (c *Client) GeoLabel(ctx context.Context, req struct {\n\tName string `json:\"name\"`\n}) error {")
+	g.Has(src, "func (c *Client) GeoReset(ctx context.Context) error {")
+	g.Has(src, "Encoding *expose.Encoding")
+
+	_, err = parser.ParseFile(token.NewFileSet(), "client.go", src, 0)
+	g.Nil(err)
+}
+
+func TestGenerateGoClientUnsupportedType(t *testing.T) {
+	g := got.T(t)
+
+	type internal struct{ Foo string }
+
+	var buf bytes.Buffer
+	err := GenerateGoClient(&buf, "client", []Function{
+		FuncNullary("/internal", func(ctx context.Context) (internal, error) {
+			return internal{}, nil
+		}),
+	})
+
+	g.Has(err.Error(), "unexported type expose.internal is not supported")
+}
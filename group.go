@@ -0,0 +1,55 @@
+package expose
+
+import "strings"
+
+// Group mounts the functions `fns` under `prefix` (see [Rebase]), e.g. to serve the versions of an API side by side:
+//
+//	fns := append(expose.Group("/v1", v1.Functions()...), expose.Group("/v2", v2.Functions()...)...)
+//
+// In addition to the spec of all functions, the [Handler] serves the spec of every group under its prefix,
+// e.g. at '/v1/swagger.json', and the swagger UI offers the specs in a dropdown.
+//
+// The spec of a group only contains the schemas of its functions. The schema identifiers do not include the group:
+// a type, that is shared by the groups, has the same identifier in every spec. Types, whose schemas diverge between the groups,
+// have to be distinct types, e.g. declared in versioned packages, which the [DefaultSchemaIdentifier] tells apart.
+func Group(prefix string, fns ...Function) []Function {
+	if strings.Trim(prefix, "/") == "" {
+		return fns
+	}
+	group := "/" + strings.Trim(prefix, "/")
+
+	grouped := make([]Function, 0, len(fns))
+	for _, fn := range fns {
+		grouped = append(grouped, &groupFunction{Function: Rebase(fn, prefix), group: group})
+	}
+	return grouped
+}
+
+// groupFunction is a function of a [Group]
+type groupFunction struct {
+	Function
+	group string
+}
+
+func (fn *groupFunction) funcSettings() functionSettings {
+	settings := getFuncSettings(fn.Function)
+	settings.group = fn.group
+	return settings
+}
+
+// functionGroups returns the names of the groups of the functions `fns` in order of their appearance and their functions
+func functionGroups(fns []Function) ([]string, map[string][]Function) {
+	var names []string
+	groups := map[string][]Function{}
+	for _, fn := range fns {
+		group := getFuncSettings(fn).group
+		if group == "" {
+			continue
+		}
+		if _, ok := groups[group]; !ok {
+			names = append(names, group)
+		}
+		groups[group] = append(groups[group], fn)
+	}
+	return names, groups
+}
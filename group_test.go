@@ -0,0 +1,77 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+type groupV1Customer struct {
+	Name string `json:"name"`
+}
+
+type groupV2Customer struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+}
+
+func TestGroup(t *testing.T) {
+	g := got.T(t)
+
+	fns := append(
+		Group("/v1", FuncNullary("/customers/get", func(ctx context.Context) (groupV1Customer, error) {
+			return groupV1Customer{Name: "Jane Doe"}, nil
+		})),
+		Group("v2/", FuncNullary("/customers/get", func(ctx context.Context) (groupV2Customer, error) {
+			return groupV2Customer{FirstName: "Jane", LastName: "Doe"}, nil
+		}))...,
+	)
+	g.Eq(fns[0].Path(), "/v1/customers/get")
+	g.Eq(fns[1].Path(), "/v2/customers/get")
+
+	h, err := NewHandler(fns, WithSwaggerUI("/swagger-ui"))
+	g.Must().Nil(err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	spec := func(path string) map[string]map[string]any {
+		w := get(path)
+		g.Must().Eq(w.Code, http.StatusOK)
+		var spec struct {
+			Paths      map[string]any `json:"paths"`
+			Components struct {
+				Schemas map[string]any `json:"schemas"`
+			} `json:"components"`
+		}
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &spec))
+		return map[string]map[string]any{"paths": spec.Paths, "schemas": spec.Components.Schemas}
+	}
+
+	all := spec("/swagger.json")
+	g.Len(all["paths"], 2)
+
+	v1 := spec("/v1/swagger.json")
+	g.Len(v1["paths"], 1)
+	g.NotNil(v1["paths"]["/v1/customers/get"])
+	g.NotNil(v1["schemas"]["github.com.pbedat.expose.groupV1Customer"])
+	g.Nil(v1["schemas"]["github.com.pbedat.expose.groupV2Customer"])
+
+	v2 := spec("/v2/swagger.json")
+	g.Len(v2["paths"], 1)
+	g.NotNil(v2["paths"]["/v2/customers/get"])
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v2/customers/get", nil))
+	g.Eq(w.Code, http.StatusOK)
+	g.Has(w.Body.String(), "firstName")
+
+	ui := get("/swagger-ui/swagger-initializer.js").Body.String()
+	g.Has(ui, `{"name":"all","url":"/swagger.json"},{"name":"v1","url":"/v1/swagger.json"},{"name":"v2","url":"/v2/swagger.json"}`)
+}
@@ -1,190 +1,760 @@
 package expose
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math"
+	"mime"
 	"net/http"
 	"path"
-	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/flowchartsman/swaggerui"
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/mitchellh/mapstructure"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handler handles RPC requests. See [NewHandler]
 type Handler struct {
 	http.Handler
+	fns []Function
+	// byPath maps the paths and aliases to their functions, see [Handler.Invoke]
+	byPath         map[string]Function
+	validationSpec openapi3.T
+	fieldNames     *fieldNamer
+	interceptors   []Interceptor
+	// appErrorStatus is the status of application errors, see [WithDefaultErrorStatus]
+	appErrorStatus int
+	// spec reflects the spec on its first call
+	spec func() (openapi3.T, error)
+}
+
+// Functions returns the functions, that are exposed by the handler
+func (h *Handler) Functions() []Function {
+	return slices.Clone(h.fns)
+}
+
+// Spec returns the openapi spec of the handler, as it is served at the swagger.json path (see [WithSwaggerJSONPath]).
+// The spec is reflected on first use, which fails e.g. when a type can not be described by a schema.
+func (h *Handler) Spec() (openapi3.T, error) {
+	return h.spec()
 }
 
 type handlerSettings struct {
 	*reflectSettings
-	errorHandler  ErrorHandler
-	defaultSpec   openapi3.T
-	encoding      map[string]Encoding
-	middlewares   []Middleware
-	swaggerPath   string
-	swaggerUIPath string
-	basePath      string
+	errorHandler ErrorHandler
+	defaultSpec  openapi3.T
+	encoding     map[string]Encoding
+	// fieldTransform and int64Strings define the wire format of the JSON encodings, see [WithFieldNames] and [WithInt64AsString]
+	fieldTransform func(name string) string
+	int64Strings   bool
+	// errorFormatter creates the body of the error responses, see [WithErrorFormatter]
+	errorFormatter ErrorFormatter
+	// defaultEncoding decodes requests without content-type, see [WithDefaultEncoding]
+	defaultEncoding string
+	middlewares     []Middleware
+	interceptors    []Interceptor
+	swaggerPath     string
+	// specCacheControl is the Cache-Control header of the spec, see [WithSpecCacheControl]
+	specCacheControl string
+	// requestServerURL sets the server url of the served spec per request, see [WithRequestServerURL]
+	requestServerURL bool
+	// specProcessors transform the reflected spec, see [WithSpecPostProcessor]
+	specProcessors []func(spec *openapi3.T) error
+	// firstMiddlewares and lastMiddlewares run before and after the middlewares, see [WithMiddlewareFirst] and [WithMiddlewareLast]
+	firstMiddlewares []Middleware
+	lastMiddlewares  []Middleware
+	swaggerUIPath    string
+	indexPath        string
+	// notFoundHandler responds to unknown paths, see [WithNotFoundHandler]
+	notFoundHandler http.Handler
+	// timeout limits the duration of calls, see [WithTimeout]
+	timeout time.Duration
+	// timeoutHeader carries the timeouts of calls in milliseconds, see [WithHeaderTimeout]
+	timeoutHeader    string
+	maxHeaderTimeout time.Duration
+	// concurrency is the semaphore of the calls, see [WithMaxConcurrency]
+	concurrency chan struct{}
+	// requestIDHeader is the header of the request ids, see [WithRequestID]
+	requestIDHeader string
+	idempotency     IdempotencyStore
+	cacheStore      CacheStore
+	basePath        string
+	dereference     bool
+	auth            AuthFunc
+	tracer          trace.Tracer
+	debugPath       string
+	logger          LogFunc
+	// appErrorStatus and internalErrorStatus are the default status codes of the error responses
+	appErrorStatus      int
+	internalErrorStatus int
+	errorCodeStatus     map[string]int
+	validateResponses   bool
+	cors                *CORSConfig
+	// maxBodyBytes limits the size of request bodies
+	maxBodyBytes       int64
+	maxMultipartMemory int64
+	// spec is served instead of the reflected spec, see [WithSpec]
+	spec *openapi3.T
+	// tags are added to the tags of the default spec
+	tags openapi3.Tags
+	// securitySchemes are added to the components of the default spec
+	securitySchemes openapi3.SecuritySchemes
+	// specExtensions are added to the extensions of the default spec, see [WithSpecExtension]
+	specExtensions map[string]any
 }
 
 // ErrorHandler is called, when a exposed function returns an error.
 // Returning `handled == true` cancels any further error handling.
 type ErrorHandler func(w http.ResponseWriter, enc Encoder, err error) (handled bool)
 
+// ErrorFormatter creates the body of the error response for `err`, which is encoded like the results.
+// `ctx` is the context of the call, e.g. to include the [RequestID] or the [Principal].
+// Unlike an [ErrorHandler], it does not write the response, so the status and the headers are still derived from the error.
+type ErrorFormatter func(ctx context.Context, err error) any
+
+// AuthFunc authenticates a request, before it is decoded.
+// The returned context is passed to the exposed function, e.g. to provide the authenticated principal (see [WithContextValue]).
+// It should identify the caller with [WithPrincipal], unless the credentials are passed in the `Authorization` or `Cookie` header.
+// When an error is returned, the handler responds with 401 Unauthorized.
+type AuthFunc func(ctx context.Context, r *http.Request) (context.Context, error)
+
 type HandlerOption func(settings *handlerSettings)
 
 type Middleware func(next http.Handler) http.Handler
 
-// NewHandler creates a http handler, that provides the exposed functions as HTTP POST endpoints.
+// NewHandler creates a http handler, that provides the exposed functions as HTTP POST endpoints (see [Methods] for other methods).
 // see [Handler]
 // Requests and responses are encoded with JSON by default.
-// The handler also provides the openapi spec at the path '/swagger.json'
+// The handler also provides the openapi spec at the path '/swagger.json'. The spec is reflected, when it is first requested.
 //
 // When an exposed function returns an error, the handler will respond with HTTP status 500 Internal Server Error by default.
 // When the error is (see [errors.Is]) an [ErrApplication], the status 422 Unprocessable Entity will be returned instead.
+// The defaults can be changed with [WithDefaultErrorStatus] and errors can carry their own status (see [SetErrStatus]).
 // Errors can be marked with custom codes [SetErrCode], which will be included in the error response.
+// The body of the error responses can be replaced with an [ErrorFormatter].
 // To customize the error handling further, a [ErrorHandler] can be provided.
 func NewHandler(fns []Function, options ...HandlerOption) (*Handler, error) {
 
-	settings := &handlerSettings{
-		reflectSettings: &reflectSettings{
-			mapper:    func(t reflect.Type) *openapi3.Schema { return nil },
-			typeNamer: DefaultSchemaIdentifier,
-		},
-		defaultSpec: openapi3.T{},
-		encoding: map[string]Encoding{
-			"*/*":              JsonEncoding,
-			"application/json": JsonEncoding,
-		},
-		swaggerPath: "/swagger.json",
+	settings := newHandlerSettings(options...)
+	fns, catchAlls, err := splitCatchAlls(fns)
+	if err != nil {
+		return nil, err
 	}
-	for _, applyOption := range options {
-		applyOption(settings)
+	if settings.module != nil {
+		modular := make([]Function, len(fns))
+		for i, fn := range fns {
+			modular[i] = settings.withModule(fn)
+		}
+		fns = modular
 	}
 
-	validationSpec, err := ReflectSpec(settings.defaultSpec, fns, withSettings(*settings.reflectSettings), SkipExtractSubSchemas())
-	if err != nil {
+	if err := checkDuplicatePaths(fns); err != nil {
+		return nil, err
+	}
+	if err := checkMethods(fns); err != nil {
+		return nil, err
+	}
+	if err := checkRequestDefaults(fns); err != nil {
 		return nil, err
 	}
 
+	if _, ok := settings.encoding[settings.defaultEncoding]; !ok {
+		return nil, fmt.Errorf("the default encoding '%s' is not registered", settings.defaultEncoding)
+	}
+
+	for key := range settings.specExtensions {
+		if err := checkExtensionKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	// the spec is reflected once, the validation spec and the served spec are derived from it, unless the served spec is processed
+	reflected := sync.OnceValues(func() (openapi3.T, error) {
+		return settings.reflectResolvedSpec(fns, false)
+	})
+
+	// the validation spec is only reflected on startup, when requests or responses are validated
+	var validationSpec openapi3.T
+	if settings.validateResponses || slices.ContainsFunc(fns, needsValidationSpec) {
+		var err error
+		if validationSpec, err = reflected(); err != nil {
+			return nil, err
+		}
+	}
+
 	r := http.NewServeMux()
+	byPath := map[string]Function{}
 
 	for _, _fn := range fns {
 		fn := _fn
-		r.HandleFunc(fn.Path(), func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodPost {
-				http.Error(w, fmt.Sprint("use method POST instead of ", r.Method), http.StatusBadRequest)
+		handleFn := func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(settings.assignRequestID(r.Context(), w, r))
+			if methods := functionMethods(fn); !slices.Contains(methods, r.Method) {
+				w.Header().Set("Allow", strings.Join(methods, ", "))
+				http.Error(w, fmt.Sprintf("use method %s instead of %s", strings.Join(methods, " or "), r.Method), http.StatusMethodNotAllowed)
 				return
 			}
 
-			contentType := r.Header.Get("content-type")
-			if contentType == "" {
-				for mimeType := range settings.encoding {
-					contentType = mimeType
-					break
-				}
+			enc, ok := settings.negotiateEncodings(w, r)
+			if !ok {
+				return
 			}
 
-			reqEncoding, hasReqEncoding := settings.encoding[contentType]
-			if !hasReqEncoding {
-				http.Error(w, fmt.Sprintf("content-type '%s' is not supported", contentType), http.StatusBadRequest)
+			ctx, span := settings.startSpan(r, fn)
+			defer span.End()
+
+			if ctx, ok = settings.authenticate(ctx, w, r, span, enc.errorEncoding()); !ok {
 				return
 			}
 
-			dec := reqEncoding.GetDecoder(r.Body)
+			// cached and recorded responses are only served to authenticated callers
+			serve := settings.cached(fn, settings.idempotent(fn, func(w http.ResponseWriter, r *http.Request) {
+				settings.serveFunction(w, r, fn, enc, span, validationSpec)
+			}))
+			serve(w, r.WithContext(ctx))
+		}
 
-			res, err := fn.Apply(r.Context(), dec, validationSpec)
+		r.HandleFunc(fn.Path(), handleFn)
+		byPath[fn.Path()] = fn
+		for _, alias := range getFuncSettings(fn).aliases {
+			r.HandleFunc(alias, handleFn)
+			byPath[alias] = fn
+		}
+	}
 
-			accept := r.Header.Get("accept")
-			if accept == "" {
-				accept = contentType
-			}
-			resEncoding, hasResEncoding := settings.encoding[accept]
+	// the spec is only reflected, when it is requested, since reflecting large APIs slows down the startup
+	spec := sync.OnceValues(func() (openapi3.T, error) {
+		if settings.spec != nil {
+			return *settings.spec, nil
+		}
+		if len(settings.specProcessors) > 0 {
+			// the processors change the spec in place, so the validation spec is not shared
+			return settings.reflectSpec(fns)
+		}
+		spec, err := reflected()
+		if err != nil {
+			return openapi3.T{}, err
+		}
+		return settings.servedSpec(spec)
+	})
 
-			if err != nil {
-				if hasResEncoding {
-					encoder := resEncoding.GetEncoder(w)
-					if settings.errorHandler != nil {
-						if handled := settings.errorHandler(w, encoder, err); handled {
-							return
-						}
-					}
-					if errors.Is(err, ErrApplication) {
-						w.WriteHeader(http.StatusUnprocessableEntity)
-					} else {
-						w.WriteHeader(500)
-					}
-					m := map[string]any{}
-					if err := mapstructure.Decode(err, &m); err != nil {
-						panic(err)
-					}
-					m["message"] = err.Error()
-
-					if code, ok := GetErrCode(err); ok {
-						m["code"] = code
-					}
-
-					encoder.Encode(m)
-					return
-				} else {
-					if handled := settings.errorHandler(w, nil, err); handled {
-						return
-					}
+	groups, groupFns := functionGroups(fns)
+	uiSpecs := []SwaggerUISpec{{Name: "all", URL: path.Join("/", settings.basePath, settings.swaggerPath)}}
+
+	if settings.swaggerPath != "" {
+		r.HandleFunc(settings.swaggerPath, newSpecHandler(settings, spec))
+
+		// every group has its own spec, that is reflected like the spec of all functions
+		for _, group := range groups {
+			fns := groupFns[group]
+			groupSpec := func() (openapi3.T, error) {
+				spec, err := settings.reflectResolvedSpec(fns, true)
+				if err != nil {
+					return openapi3.T{}, fmt.Errorf("%s: %w", group, err)
 				}
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+				return settings.servedSpec(spec)
 			}
+			r.HandleFunc(group+settings.swaggerPath, newSpecHandler(settings, groupSpec))
+			uiSpecs = append(uiSpecs, SwaggerUISpec{
+				Name: group[1:],
+				URL:  path.Join("/", settings.basePath, group, settings.swaggerPath),
+			})
+		}
+	}
+
+	if settings.swaggerUIPath != "" {
+		r.HandleFunc(settings.swaggerUIPath, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, path.Join(settings.basePath, settings.swaggerUIPath)+"/", http.StatusSeeOther)
+		})
+		// the UI loads the served spec, unless the spec is not served
+		var ui *SwaggerUIHandler
+		if settings.swaggerPath != "" && len(uiSpecs) > 1 {
+			ui = NewSwaggerUIHandlerForURLs(uiSpecs...)
+		} else if settings.swaggerPath != "" {
+			ui = NewSwaggerUIHandlerForURL(uiSpecs[0].URL)
+		} else {
+			ui = newSwaggerUIHandler(settings, spec)
+		}
+		r.Handle(settings.swaggerUIPath+"/", http.StripPrefix(settings.swaggerUIPath, ui))
+	}
 
-			if _, ok := res.(Void); ok {
+	if settings.debugPath != "" {
+		r.HandleFunc(settings.debugPath, newDebugHandler(settings, fns))
+	}
+
+	notFound := newNotFoundHandler(settings, fns)
+	for _, catchAll := range catchAlls {
+		if catchAll.pattern() == "/" {
+			notFound = settings.serveCatchAll(catchAll)
+			continue
+		}
+		r.HandleFunc(catchAll.pattern(), settings.serveCatchAll(catchAll))
+	}
+	if settings.indexPath == "/" {
+		// the root pattern matches all paths
+		index := newIndexHandler(settings, fns)
+		unknown := notFound
+		notFound = func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				unknown(w, r)
 				return
 			}
+			index(w, r)
+		}
+	} else if settings.indexPath != "" {
+		r.HandleFunc(settings.indexPath, newIndexHandler(settings, fns))
+	}
 
-			if !hasResEncoding {
-				http.Error(w, fmt.Sprintf("response format '%s' not suppported", accept), http.StatusBadRequest)
-				return
+	r.HandleFunc("/", notFound)
+
+	var h http.Handler = r
+	if settings.basePath != "" {
+		h = http.StripPrefix(settings.basePath, h)
+	}
+	for i := len(settings.lastMiddlewares) - 1; i >= 0; i-- {
+		h = settings.lastMiddlewares[i](h)
+	}
+	for _, mw := range settings.middlewares {
+		h = mw(h)
+	}
+	for i := len(settings.firstMiddlewares) - 1; i >= 0; i-- {
+		h = settings.firstMiddlewares[i](h)
+	}
+	// preflight requests must be answered before e.g. an authentication middleware rejects them
+	if settings.cors != nil {
+		h = newCORSMiddleware(*settings.cors)(h)
+	}
+
+	return &Handler{
+		Handler:        h,
+		fns:            slices.Clone(fns),
+		byPath:         byPath,
+		validationSpec: validationSpec,
+		fieldNames:     settings.fieldNames,
+		interceptors:   settings.interceptors,
+		appErrorStatus: settings.appErrorStatus,
+		spec:           spec,
+	}, nil
+}
+
+// needsValidationSpec reports whether `fn` validates its requests (see [Validate]).
+// Custom functions receive the spec in [Function.Apply] and might use it, so they always need it.
+func needsValidationSpec(fn Function) bool {
+	p, ok := fn.(settingsProvider)
+	return !ok || p.funcSettings().validate
+}
+
+// callEncodings are the encodings of a call, that are negotiated from the `content-type` and `accept` headers of the request
+type callEncodings struct {
+	contentType string
+	// boundary separates the parts of multipart requests
+	boundary string
+	req      Encoding
+	hasReq   bool
+	accept   string
+	res      Encoding
+	hasRes   bool
+}
+
+// errorEncoding returns the encoding of the error responses, which are written as plain text without a response encoding
+func (enc callEncodings) errorEncoding() *Encoding {
+	if !enc.hasRes {
+		return nil
+	}
+	return &enc.res
+}
+
+// negotiateEncodings negotiates the encodings of the request `r`. When the request can not be decoded, it responds with 400 Bad Request.
+func (settings *handlerSettings) negotiateEncodings(w http.ResponseWriter, r *http.Request) (callEncodings, bool) {
+	enc := callEncodings{contentType: r.Header.Get("content-type")}
+	if enc.contentType == "" {
+		enc.contentType = settings.defaultEncoding
+	} else {
+		mediaType, params, err := mime.ParseMediaType(enc.contentType)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid content-type '%s': %s", enc.contentType, err), http.StatusBadRequest)
+			return enc, false
+		}
+		enc.contentType = mediaType
+		enc.boundary = params["boundary"]
+	}
+
+	enc.req, enc.hasReq = settings.encoding[enc.contentType]
+	// multipart forms are decoded by the handler, see [FileUpload]
+	if !enc.hasReq && enc.contentType != multipartFormData {
+		http.Error(w, fmt.Sprintf("content-type '%s' is not supported", enc.contentType), http.StatusBadRequest)
+		return enc, false
+	}
+
+	enc.accept = r.Header.Get("accept")
+	if enc.accept == "" {
+		enc.accept = enc.contentType
+		// the response is encoded with the default encoding, when the request encoding can not encode
+		if enc.req.GetEncoder == nil {
+			enc.accept = settings.defaultEncoding
+		}
+	}
+	enc.res, enc.hasRes = negotiateEncoding(settings.encoding, enc.accept)
+	if enc.hasRes && enc.res.GetEncoder == nil {
+		enc.hasRes = false
+	}
+	return enc, true
+}
+
+// authenticate authenticates the request `r` with the [AuthFunc] of the handler.
+// When the authentication fails, it responds with 401 Unauthorized.
+func (settings *handlerSettings) authenticate(ctx context.Context, w http.ResponseWriter, r *http.Request, span trace.Span, errEncoding *Encoding) (context.Context, bool) {
+	if settings.auth == nil {
+		return ctx, true
+	}
+	authCtx, err := settings.auth(ctx, r)
+	if err != nil {
+		failSpan(span, err)
+		settings.writeError(ctx, w, errEncoding, SetErrStatus(err, http.StatusUnauthorized))
+		return ctx, false
+	}
+	return authCtx, true
+}
+
+// limitBody limits the body of the request `r` to the max body bytes of `fn` or of the handler, see [MaxBodyBytes]
+func (settings *handlerSettings) limitBody(w http.ResponseWriter, r *http.Request, fn Function) io.Reader {
+	maxBodyBytes := settings.maxBodyBytes
+	if n := getFuncSettings(fn).maxBodyBytes; n > 0 {
+		maxBodyBytes = n
+	}
+	if maxBodyBytes <= 0 {
+		return r.Body
+	}
+	return http.MaxBytesReader(w, r.Body, maxBodyBytes)
+}
+
+// call applies `fn` within the concurrency limit (see [WithMaxConcurrency]) and through the interceptors (see [WithInterceptor]).
+// `ctx` carries the timeout of the call (see [WithTimeout]), whose expiry is reported as the error of the call.
+func (settings *handlerSettings) call(ctx context.Context, fn Function, apply func(ctx context.Context) (any, error)) (any, error) {
+	res, err := settings.limitConcurrency(func() (any, error) {
+		return intercept(ctx, settings.interceptors, fn, apply)
+	})
+	return res, contextError(ctx, err)
+}
+
+// serveFunction decodes the authenticated request `r`, calls `fn` and encodes its result
+func (settings *handlerSettings) serveFunction(w http.ResponseWriter, r *http.Request, fn Function, enc callEncodings, span trace.Span, validationSpec openapi3.T) {
+	ctx := r.Context()
+	errEncoding := enc.errorEncoding()
+
+	var body io.Reader = settings.limitBody(w, r, fn)
+	var counter *countingReader
+	var start time.Time
+	if settings.logger != nil {
+		counter = &countingReader{Reader: body}
+		body = counter
+		start = time.Now()
+	}
+
+	applyCtx, applySpan := settings.startPhase(ctx, "apply")
+	var reqDecoder Decoder
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		reqDecoder = DecoderFunc(func(v any) error {
+			return decodeForm(query, v)
+		})
+	} else if enc.contentType == multipartFormData && !enc.hasReq {
+		multipartDec := &multipartDecoder{body: body, boundary: enc.boundary, maxMemory: settings.maxMultipartMemory}
+		defer multipartDec.close()
+		reqDecoder = multipartDec
+	} else {
+		reqDecoder = enc.req.decoder(r, body)
+	}
+	dec := settings.traceDecoder(applyCtx, reqDecoder)
+
+	applyCtx, cancel := settings.withTimeout(applyCtx, r)
+	defer cancel()
+
+	headers := http.Header{}
+	res, err := settings.call(withFieldNamer(withResponseHeaders(applyCtx, headers), settings.fieldNames), fn, func(ctx context.Context) (any, error) {
+		return fn.Apply(ctx, dec, validationSpec)
+	})
+	failSpan(applySpan, err)
+	applySpan.End()
+
+	for name, values := range headers {
+		w.Header()[name] = values
+	}
+
+	if settings.logger != nil {
+		settings.logCall(ctx, fn, start, counter.n, err)
+	}
+	if err != nil {
+		failSpan(span, err)
+		settings.writeError(ctx, w, errEncoding, err)
+		return
+	}
+
+	if isVoid(res) {
+		return
+	}
+	if blob, ok := res.(Blob); ok {
+		_, encodeSpan := settings.startPhase(ctx, "encode")
+		defer encodeSpan.End()
+		// the response has already started, so the failure can only be recorded
+		failSpan(encodeSpan, writeBlob(w, blob))
+		return
+	}
+	if settings.envelope != nil {
+		res = settings.envelope(res)
+	}
+
+	if settings.validateResponses {
+		if err := validateResponse(validationSpec, fn, res, settings.fieldNames); err != nil {
+			settings.writeError(ctx, w, errEncoding, SetErrStatus(err, http.StatusInternalServerError))
+			return
+		}
+	}
+
+	if !enc.hasRes {
+		http.Error(w, fmt.Sprintf("response format '%s' not suppported", enc.accept), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("content-type", enc.res.MimeType)
+
+	_, encodeSpan := settings.startPhase(ctx, "encode")
+	defer encodeSpan.End()
+	if err := enc.res.GetEncoder(w).Encode(res); err != nil {
+		// the encoders fail before they write, e.g. when the result is not supported by the encoding
+		failSpan(encodeSpan, err)
+		settings.writeError(ctx, w, nil, fmt.Errorf("failed to encode the result: %w", err))
+	}
+}
+
+// newHandlerSettings applies the `options` to the default settings of the [Handler]
+func newHandlerSettings(options ...HandlerOption) *handlerSettings {
+	reflection := newReflectSettings()
+	settings := &handlerSettings{
+		reflectSettings: &reflection,
+		defaultSpec:     openapi3.T{},
+		cacheStore:      NewMemoryCacheStore(),
+		encoding: map[string]Encoding{
+			"*/*":              JsonEncoding,
+			"application/json": JsonEncoding,
+		},
+		defaultEncoding:     "application/json",
+		maxMultipartMemory:  defaultMaxMultipartMemory,
+		swaggerPath:         "/swagger.json",
+		specCacheControl:    "no-cache",
+		appErrorStatus:      http.StatusUnprocessableEntity,
+		internalErrorStatus: http.StatusInternalServerError,
+	}
+	for _, applyOption := range options {
+		applyOption(settings)
+	}
+
+	if len(settings.securitySchemes) > 0 {
+		components := openapi3.NewComponents()
+		if settings.defaultSpec.Components != nil {
+			components = *settings.defaultSpec.Components
+		}
+		securitySchemes := openapi3.SecuritySchemes{}
+		for name, scheme := range components.SecuritySchemes {
+			securitySchemes[name] = scheme
+		}
+		for name, scheme := range settings.securitySchemes {
+			securitySchemes[name] = scheme
+		}
+		components.SecuritySchemes = securitySchemes
+		settings.defaultSpec.Components = &components
+	}
+
+	if len(settings.tags) > 0 {
+		tags := slices.Clone(settings.defaultSpec.Tags)
+		for _, tag := range settings.tags {
+			i := slices.IndexFunc(tags, func(t *openapi3.Tag) bool { return t.Name == tag.Name })
+			if i < 0 {
+				tags = append(tags, tag)
+				continue
 			}
+			tags[i] = tag
+		}
+		settings.defaultSpec.Tags = tags
+	}
 
-			w.Header().Set("content-type", resEncoding.MimeType)
+	if len(settings.specExtensions) > 0 {
+		extensions := maps.Clone(settings.defaultSpec.Extensions)
+		if extensions == nil {
+			extensions = map[string]any{}
+		}
+		maps.Copy(extensions, settings.specExtensions)
+		settings.defaultSpec.Extensions = extensions
+	}
 
-			if err := resEncoding.GetEncoder(w).Encode(res); err != nil {
-				panic(fmt.Errorf("failed to encode: %+v", res))
+	settings.reflectSettings.fieldNames = newFieldNamer(settings.fieldTransform, settings.int64Strings)
+	if settings.fieldNames != nil {
+		for mimeType, enc := range settings.encoding {
+			if isJSONMimeType(enc.MimeType) {
+				settings.encoding[mimeType] = withFieldNames(enc, settings.fieldNames)
 			}
-		})
+		}
 	}
 
-	if settings.swaggerPath != "" {
-		spec, err := ReflectSpec(settings.defaultSpec, fns, withSettings(*settings.reflectSettings))
-		if err != nil {
-			return nil, fmt.Errorf("failed to reflect spec: %w", err)
+	for code, status := range settings.errorCodeStatus {
+		if settings.reflectSettings.errorResponses == nil {
+			settings.reflectSettings.errorResponses = map[int][]string{}
 		}
-		r.HandleFunc(settings.swaggerPath, func(w http.ResponseWriter, r *http.Request) {
-			if err := json.NewEncoder(w).Encode(spec); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+		settings.reflectSettings.errorResponses[status] = append(settings.reflectSettings.errorResponses[status], code)
+	}
+
+	return settings
+}
+
+// checkDuplicatePaths fails, when multiple functions are mounted at the same path, including their [Aliases]
+func checkDuplicatePaths(fns []Function) error {
+	mounted := map[string]Function{}
+	for _, fn := range fns {
+		for _, p := range append([]string{fn.Path()}, getFuncSettings(fn).aliases...) {
+			if other, ok := mounted[p]; ok {
+				return fmt.Errorf("duplicate path %s: exposed by %s#%s (%s) and %s#%s (%s)",
+					p, other.Module(), other.Name(), other.Path(), fn.Module(), fn.Name(), fn.Path())
 			}
-		})
+			mounted[p] = fn
+		}
 	}
+	return nil
+}
 
-	if settings.swaggerUIPath != "" {
-		r.HandleFunc(settings.swaggerUIPath, func(w http.ResponseWriter, r *http.Request) {
-			http.Redirect(w, r, path.Join(settings.basePath, settings.swaggerUIPath)+"/", http.StatusSeeOther)
-		})
-		r.Handle(
-			settings.swaggerUIPath+"/",
-			http.StripPrefix(settings.swaggerUIPath,
-				NewSwaggerUIHandler(settings.defaultSpec, fns)))
+// BuildSpec reflects the openapi spec of the functions `fns`, just like the [Handler] created with the same `options` does.
+// Use it to post-process the spec and serve it with [WithSpec].
+func BuildSpec(fns []Function, options ...HandlerOption) (openapi3.T, error) {
+	return newHandlerSettings(options...).reflectSpec(fns)
+}
+
+// reflectSpec reflects the spec, that is served by the handler
+func (settings *handlerSettings) reflectSpec(fns []Function) (openapi3.T, error) {
+	spec, err := settings.reflectResolvedSpec(fns, true)
+	if err != nil {
+		return openapi3.T{}, err
 	}
+	return settings.servedSpec(spec)
+}
 
-	r.HandleFunc("/", http.NotFound)
+// reflectResolvedSpec reflects the spec with resolved $refs, as they are required for the validation.
+// Resolved $refs are still encoded as $refs, so the resolution does not change the served spec.
+// The served spec is transformed by the spec processors (see [WithSpecPostProcessor]), when `process` is set.
+func (settings *handlerSettings) reflectResolvedSpec(fns []Function, process bool) (openapi3.T, error) {
+	spec, err := ReflectSpec(settings.defaultSpec, fns, withSettings(*settings.reflectSettings))
+	if err != nil {
+		return openapi3.T{}, fmt.Errorf("failed to reflect spec: %w", err)
+	}
+	if !process {
+		return spec, resolveRefs(&spec)
+	}
+	for _, process := range settings.specProcessors {
+		if err := process(&spec); err != nil {
+			return openapi3.T{}, fmt.Errorf("failed to process the spec: %w", err)
+		}
+	}
+	return spec, resolveRefs(&spec)
+}
 
-	var h http.Handler = r
-	for _, mw := range settings.middlewares {
-		h = mw(h)
+func resolveRefs(spec *openapi3.T) error {
+	if err := openapi3.NewLoader().ResolveRefsIn(spec, nil); err != nil {
+		return fmt.Errorf("failed to resolve the spec: %w", err)
+	}
+	return nil
+}
+
+// servedSpec derives the served spec from the reflected `spec`
+func (settings *handlerSettings) servedSpec(spec openapi3.T) (openapi3.T, error) {
+	if settings.dereference {
+		return dereferenceSpec(spec)
+	}
+	return spec, nil
+}
+
+// writeError responds with `err`. The error is encoded with `enc` or written as plain text, when `enc` is nil.
+// A custom [ErrorHandler] takes precedence, a custom [ErrorFormatter] replaces the encoded body.
+func (settings *handlerSettings) writeError(ctx context.Context, w http.ResponseWriter, enc *Encoding, err error) {
+	var encoder Encoder
+	if enc != nil {
+		encoder = enc.GetEncoder(w)
+	}
+	if settings.errorHandler != nil {
+		if handled := settings.errorHandler(w, encoder, err); handled {
+			return
+		}
+	}
+
+	retryAfter, retryable := GetErrRetryable(err)
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	if enc == nil {
+		http.Error(w, err.Error(), settings.errorStatus(err))
+		return
+	}
+
+	w.Header().Set("content-type", enc.MimeType)
+	w.WriteHeader(settings.errorStatus(err))
+
+	if settings.errorFormatter != nil {
+		encoder.Encode(settings.errorFormatter(ctx, err))
+		return
 	}
+	encoder.Encode(errorBody(ctx, err, retryable))
+}
 
-	return &Handler{h}, nil
+// errorBody is the default body of the error response for `err`: its exported fields,
+// with the message, the code, the field of a [FieldError], whether it is retryable and the request id
+func errorBody(ctx context.Context, err error, retryable bool) map[string]any {
+	m := map[string]any{}
+	if err := mapstructure.Decode(err, &m); err != nil {
+		panic(err)
+	}
+	m["message"] = err.Error()
+
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		m["field"] = fieldErr.Field
+		m["expected"] = fieldErr.Expected
+	}
+	if code, ok := GetErrCode(err); ok {
+		m["code"] = code
+	}
+	if retryable {
+		m["retryable"] = true
+	}
+	if id := RequestID(ctx); id != "" {
+		m["requestId"] = id
+	}
+	return m
+}
+
+// errorStatus returns the http status of the error response for `err`.
+// Errors can define their own status (see [WithStatus]) or have a code mapped to a status (see [WithErrorCodeStatus]),
+// otherwise the defaults (see [WithDefaultErrorStatus]) apply.
+func (settings *handlerSettings) errorStatus(err error) int {
+	if status, ok := GetErrStatus(err); ok {
+		return status
+	}
+	if code, ok := GetErrCode(err); ok {
+		if status, ok := settings.errorCodeStatus[code]; ok {
+			return status
+		}
+	}
+	if errors.Is(err, ErrApplication) {
+		return settings.appErrorStatus
+	}
+	return settings.internalErrorStatus
 }
 
 var ErrApplication = errors.New("application error")
@@ -193,22 +763,47 @@ type SwaggerUIHandler struct {
 	http.Handler
 }
 
+// NewSwaggerUIHandler serves the swagger UI for the spec of the functions `fns`.
+// The spec is reflected on the first request. When the spec is served already, use [NewSwaggerUIHandlerForURL] instead.
 func NewSwaggerUIHandler(defaultSpec openapi3.T, fns []Function) *SwaggerUIHandler {
+	return newSwaggerUIHandler(&handlerSettings{}, func() (openapi3.T, error) {
+		return ReflectSpec(defaultSpec, fns)
+	})
+}
 
-	spec, err := ReflectSpec(defaultSpec, fns)
-
-	if err != nil {
-		panic(err)
+// newSwaggerUIHandler serves the swagger UI with the embedded `spec`, which is requested once.
+// Specs with the server url of the request (see [WithRequestServerURL]) are encoded per request.
+func newSwaggerUIHandler(settings *handlerSettings, spec func() (openapi3.T, error)) *SwaggerUIHandler {
+	spec = sync.OnceValues(spec)
+	specJsonOnce := sync.OnceValues(func() ([]byte, error) {
+		spec, err := spec()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&spec)
+	})
+	requestSpecJson := func(r *http.Request) ([]byte, error) {
+		spec, err := spec()
+		if err != nil {
+			return nil, err
+		}
+		spec = settings.requestSpec(spec, r)
+		return json.Marshal(&spec)
 	}
 
 	return &SwaggerUIHandler{
 
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			spec := spec
-
-			specJson, err := json.Marshal(spec)
+			var specJson []byte
+			var err error
+			if settings.requestServerURL {
+				specJson, err = requestSpecJson(r)
+			} else {
+				specJson, err = specJsonOnce()
+			}
 			if err != nil {
-				panic(err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
 			}
 			swaggerui.Handler(specJson).ServeHTTP(w, r)
 		}),
@@ -0,0 +1,981 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestAliases(t *testing.T) {
+	g := got.T(t)
+
+	h, err := NewHandler([]Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}, Aliases("/old/inc")),
+	})
+	g.Must().Nil(err)
+
+	t.Run("canonical path", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/counter/inc", strings.NewReader("1")))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), "1")
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/old/inc", strings.NewReader("2")))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), "2")
+	})
+
+	t.Run("spec only advertises the canonical path", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+		g.Has(w.Body.String(), "/counter/inc")
+		g.Eq(strings.Contains(w.Body.String(), "/old/inc"), false)
+	})
+}
+
+func TestContentTypeParameters(t *testing.T) {
+	g := got.T(t)
+
+	h, err := NewHandler([]Function{
+		Func("/echo", func(ctx context.Context, s string) (string, error) {
+			return s, nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`"hello"`))
+	req.Header.Set("content-type", "application/json; charset=utf-8")
+	req.Header.Set("accept", "text/html;q=0.9, application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(w.Header().Get("content-type"), "application/json")
+	g.Eq(strings.TrimSpace(w.Body.String()), `"hello"`)
+}
+
+func TestDereferencedSpec(t *testing.T) {
+	g := got.T(t)
+
+	type address struct{ Street string }
+	type user struct {
+		Name    string
+		Address address
+		Friends []address
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/users/save", func(ctx context.Context, u user) (user, error) {
+			return u, nil
+		}),
+	}, WithDereferencedSpec())
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	g.Eq(w.Code, http.StatusOK)
+	g.Has(w.Body.String(), "Street")
+	g.Eq(strings.Contains(w.Body.String(), "$ref"), false)
+
+	// recursive types keep their self-references
+	h, err = NewHandler([]Function{
+		FuncNullary("/list/get", func(ctx context.Context) (linkedList, error) {
+			return linkedList{}, nil
+		}),
+	}, WithDereferencedSpec(), WithReflection(WithSchemaIdentifier(ShortSchemaIdentifier)))
+	g.Must().Nil(err)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+	g.Eq(w.Code, http.StatusOK)
+
+	var spec openapi3.T
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &spec))
+	list := spec.Paths.Find("/list/get").Post.Responses.Status(http.StatusOK).Value.Content.Get("application/json").Schema
+	g.Eq(list.Ref, "")
+	g.NotNil(list.Value.Properties["Value"])
+	g.Has(w.Body.String(), `"$ref": "#/components/schemas/expose.linkedList"`)
+	g.NotNil(spec.Components.Schemas["expose.linkedList"])
+}
+
+func TestSecurityScheme(t *testing.T) {
+	g := got.T(t)
+
+	h, err := NewHandler([]Function{
+		FuncNullary("/admin/stats", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}, RequireSecurity("bearer")),
+	}, WithSecurityScheme("bearer", openapi3.NewJWTSecurityScheme()))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	var spec openapi3.T
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &spec))
+
+	g.Eq(spec.Components.SecuritySchemes["bearer"].Value.Scheme, "bearer")
+	g.Eq(*spec.Paths.Find("/admin/stats").Post.Security, openapi3.SecurityRequirements{
+		{"bearer": []string{}},
+	})
+}
+
+func TestAuth(t *testing.T) {
+	type principalKey struct{}
+
+	h, err := NewHandler([]Function{
+		FuncNullary("/me", func(ctx context.Context) (string, error) {
+			return ctx.Value(principalKey{}).(string), nil
+		}),
+	}, WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+		token := r.Header.Get("authorization")
+		if token != "Bearer secret" {
+			return ctx, errors.New("invalid token")
+		}
+		return context.WithValue(ctx, principalKey{}, "alice"), nil
+	}))
+	got.T(t).Must().Nil(err)
+
+	t.Run("authenticated", func(t *testing.T) {
+		g := got.T(t)
+		req := httptest.NewRequest(http.MethodPost, "/me", nil)
+		req.Header.Set("authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), `"alice"`)
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/me", nil))
+
+		g.Eq(w.Code, http.StatusUnauthorized)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["message"], "invalid token")
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	g := got.T(t)
+
+	type person struct {
+		Age int `json:"age"`
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/people/save", func(ctx context.Context, p person) error {
+			return nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people/save", strings.NewReader(`{"age":"42"}`)))
+
+	g.Eq(w.Code, http.StatusBadRequest)
+
+	var body map[string]any
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+	g.Eq(body["message"], "invalid request body: field `age` expected number, got string")
+	g.Eq(body["field"], "age")
+	g.Eq(body["expected"], "number")
+
+	t.Run("malformed json", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people/save", strings.NewReader(`{"age":`)))
+
+		g.Eq(w.Code, http.StatusBadRequest)
+		g.Has(w.Body.String(), "invalid request body: ")
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		g := got.T(t)
+		fn := FuncVoid("/people/save", func(ctx context.Context, p person) error {
+			return fmt.Errorf("%w: age too low", ErrApplication)
+		})
+
+		_, err := fn.Apply(context.Background(), JsonEncoding.GetDecoder(strings.NewReader(`{"age"}`)), openapi3.T{})
+		g.True(errors.Is(err, ErrDecode))
+		g.False(errors.Is(err, ErrApplication))
+
+		_, err = fn.Apply(context.Background(), JsonEncoding.GetDecoder(strings.NewReader(`{"age":1}`)), openapi3.T{})
+		g.False(errors.Is(err, ErrDecode))
+		g.True(errors.Is(err, ErrApplication))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	g := got.T(t)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h, err := NewHandler([]Function{
+		FuncNullary("/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}),
+	}, WithMiddleware(trace("inner"), trace("outer")))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ping", nil))
+
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(calls, []string{"outer", "inner"})
+
+	t.Run("order", func(t *testing.T) {
+		g := got.T(t)
+		calls = nil
+		var paths []string
+		routed := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				next.ServeHTTP(w, r)
+			})
+		}
+
+		h, err := NewHandler([]Function{
+			FuncNullary("/ping", func(ctx context.Context) (string, error) {
+				return "pong", nil
+			}),
+		},
+			WithMiddlewareLast(trace("last 1"), routed),
+			WithPathPrefix("/rpc"),
+			WithMiddleware(trace("inner"), trace("outer")),
+			WithMiddlewareFirst(trace("first 1")),
+			WithMiddlewareFirst(trace("first 2")),
+			WithMiddlewareLast(trace("last 2")),
+		)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/rpc/ping", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(calls, []string{"first 1", "first 2", "outer", "inner", "last 1", "last 2"})
+		// the prefix is stripped after all middlewares
+		g.Eq(paths, []string{"/rpc/ping"})
+	})
+}
+
+func TestHandlerIntrospection(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}),
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+	}
+
+	h, err := NewHandler(fns, WithSwaggerJSONPath(""))
+	g.Must().Nil(err)
+
+	g.Eq(h.Functions(), fns)
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+	g.Len(spec.Paths.Map(), 2)
+	g.Eq(spec.Paths.Find("/counter/inc").Post.OperationID, "counter#inc")
+}
+
+func TestLazySpec(t *testing.T) {
+	g := got.T(t)
+
+	type counter struct{ N int }
+	var reflected atomic.Int32
+	h, err := NewHandler([]Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (counter, error) {
+			return counter{}, nil
+		}),
+	}, WithReflection(WithSchemaMapper(func(t reflect.Type) *openapi3.Schema {
+		if t == reflect.TypeOf(counter{}) {
+			reflected.Add(1)
+		}
+		return nil
+	})))
+	g.Must().Nil(err)
+
+	// no function validates, so there is no validation spec
+	g.Eq(reflected.Load(), int32(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+			g.Eq(w.Code, http.StatusOK)
+		}()
+	}
+	wg.Wait()
+
+	// the spec is reflected once, on the first request
+	afterRequest := reflected.Load()
+	g.Gt(afterRequest, int32(0))
+
+	_, err = h.Spec()
+	g.Must().Nil(err)
+	g.Eq(reflected.Load(), afterRequest)
+}
+
+func TestValidationSpec(t *testing.T) {
+	type counter struct {
+		N int `validate:"min=0"`
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     []FuncOpt
+		expected bool
+	}{
+		{"without validation", nil, false},
+		{"with validation", []FuncOpt{Validate(true)}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := got.T(t)
+
+			reflected := false
+			h, err := NewHandler([]Function{
+				Func("/counter/set", func(ctx context.Context, c counter) (int, error) {
+					return c.N, nil
+				}, tc.opts...),
+			}, WithReflection(WithSchemaMapper(func(t reflect.Type) *openapi3.Schema {
+				reflected = true
+				return nil
+			})))
+			g.Must().Nil(err)
+			g.Eq(reflected, tc.expected)
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/counter/set", strings.NewReader(`{"N":1}`)))
+			g.Eq(w.Code, http.StatusOK)
+		})
+	}
+}
+
+func TestPrebuiltSpec(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}),
+	}
+	opts := []HandlerOption{WithDefaultSpec(&openapi3.T{Info: &openapi3.Info{Title: "counter"}})}
+
+	spec, err := BuildSpec(fns, opts...)
+	g.Must().Nil(err)
+
+	h, err := NewHandler(fns, opts...)
+	g.Must().Nil(err)
+	served, err := h.Spec()
+	g.Must().Nil(err)
+	g.Eq(spec, served)
+
+	spec.ExternalDocs = &openapi3.ExternalDocs{URL: "https://example.com/docs"}
+
+	h, err = NewHandler(fns, append(opts, WithSpec(spec))...)
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+	var actual openapi3.T
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &actual))
+	g.Eq(actual.ExternalDocs.URL, "https://example.com/docs")
+	g.Eq(actual.Info.Title, "counter")
+}
+
+func TestSpecPostProcessor(t *testing.T) {
+	fns := []Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}, Validate(true)),
+	}
+
+	var order []string
+	addParam := func(spec *openapi3.T) error {
+		order = append(order, "param")
+		spec.Paths.Find("/counter/inc").Post.AddParameter(openapi3.NewHeaderParameter("X-Tenant").WithSchema(openapi3.NewStringSchema()))
+		return nil
+	}
+	retitle := func(spec *openapi3.T) error {
+		order = append(order, "title")
+		spec.Info = &openapi3.Info{Title: "processed"}
+		return nil
+	}
+
+	g := got.T(t)
+	h, err := NewHandler(fns, WithSpecPostProcessor(addParam), WithSpecPostProcessor(retitle))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+	g.Eq(order, []string{"param", "title"})
+	var served openapi3.T
+	g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &served))
+	g.Eq(served.Info.Title, "processed")
+	g.Eq(served.Paths.Find("/counter/inc").Post.Parameters[0].Value.Name, "X-Tenant")
+
+	t.Run("error", func(t *testing.T) {
+		g := got.T(t)
+		_, err := BuildSpec(fns, WithSpecPostProcessor(func(spec *openapi3.T) error {
+			return errors.New("boom")
+		}))
+		g.Eq(err.Error(), "failed to process the spec: boom")
+	})
+
+	t.Run("removed paths are validated", func(t *testing.T) {
+		g := got.T(t)
+		internal := Func("/internal/reset", func(ctx context.Context, n int) (int, error) {
+			return n, nil
+		}, Validate(true))
+		h, err := NewHandler(append(fns, internal), WithResponseValidation(true), WithSpecPostProcessor(func(spec *openapi3.T) error {
+			spec.Paths.Delete("/internal/reset")
+			return nil
+		}))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/internal/reset", strings.NewReader("1")))
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "1\n")
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/internal/reset", strings.NewReader(`"one"`)))
+		g.Eq(w.Code, http.StatusBadRequest)
+
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		g.Nil(spec.Paths.Find("/internal/reset"))
+	})
+}
+
+func TestTags(t *testing.T) {
+	g := got.T(t)
+
+	defaultSpec := &openapi3.T{Tags: openapi3.Tags{{Name: "counter"}}}
+
+	h, err := NewHandler([]Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			return delta, nil
+		}),
+		FuncNullary("/counter/reset", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}, WithTags("Admin")),
+	},
+		WithDefaultSpec(defaultSpec),
+		WithTagDescription("counter", "Counts things"),
+		WithTagDescription("Admin", "Maintenance operations"))
+	g.Must().Nil(err)
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+	g.Eq(spec.Paths.Find("/counter/inc").Post.Tags, []string{"counter"})
+	g.Eq(spec.Paths.Find("/counter/reset").Post.Tags, []string{"Admin"})
+	g.Eq(spec.Tags, openapi3.Tags{
+		{Name: "counter", Description: "Counts things"},
+		{Name: "Admin", Description: "Maintenance operations"},
+	})
+	g.Eq(defaultSpec.Tags[0].Description, "")
+}
+
+// csvList is decoded from a comma separated string
+type csvList []string
+
+func (l *csvList) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*l = strings.Split(s, ",")
+	return nil
+}
+
+func TestCustomUnmarshaler(t *testing.T) {
+	type query struct {
+		Tags csvList
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/tags/count", func(ctx context.Context, l csvList) (int, error) {
+			return len(l), nil
+		}),
+		Func("/tags/query", func(ctx context.Context, q *query) (int, error) {
+			return len(q.Tags), nil
+		}),
+	})
+	got.T(t).Must().Nil(err)
+
+	for path, body := range map[string]string{
+		"/tags/count": `"a,b,c"`,
+		"/tags/query": `{"Tags":"a,b,c"}`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			g := got.T(t)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+
+			g.Eq(w.Code, http.StatusOK)
+			g.Eq(strings.TrimSpace(w.Body.String()), "3")
+		})
+	}
+}
+
+func TestDuplicatePaths(t *testing.T) {
+	inc := func(ctx context.Context, delta int) (int, error) {
+		return delta, nil
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		g := got.T(t)
+		_, err := NewHandler([]Function{
+			Func("/counter/inc", inc),
+			Func("/counter/inc", inc),
+		})
+		g.Eq(err.Error(), "duplicate path /counter/inc: exposed by counter#inc (/counter/inc) and counter#inc (/counter/inc)")
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		g := got.T(t)
+		_, err := NewHandler([]Function{
+			Func("/counter/inc", inc),
+			Func("/counter/add", inc, Aliases("/counter/inc")),
+		})
+		g.Eq(err.Error(), "duplicate path /counter/inc: exposed by counter#inc (/counter/inc) and counter#add (/counter/add)")
+	})
+}
+
+func TestEmptyBody(t *testing.T) {
+	type filter struct {
+		Query string `json:",omitempty"`
+		Limit int
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/items/search", func(ctx context.Context, f filter) (filter, error) {
+			return f, nil
+		}),
+		Func("/items/validated", func(ctx context.Context, f filter) (filter, error) {
+			return f, nil
+		}, Validate(true)),
+		Func("/items/pointer", func(ctx context.Context, f *filter) (filter, error) {
+			return *f, nil
+		}),
+	})
+	got.T(t).Must().Nil(err)
+
+	t.Run("zero value", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/search", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), `{"Limit":0}`)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/pointer", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), `{"Limit":0}`)
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/validated", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+	})
+
+	t.Run("truncated body", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/search", strings.NewReader(`{"Limit":`)))
+
+		g.Eq(w.Code, http.StatusBadRequest)
+	})
+
+	t.Run("optional in the spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		data, err := json.Marshal(spec.Paths.Find("/items/search").Post.RequestBody)
+		g.Must().Nil(err)
+		g.False(strings.Contains(string(data), `"required":true`))
+	})
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	echo := func(ctx context.Context, s string) (int, error) {
+		return len(s), nil
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/text/small", echo),
+		Func("/text/upload", echo, MaxBodyBytes(1024)),
+	}, WithMaxBodyBytes(16))
+	got.T(t).Must().Nil(err)
+
+	body := `"` + strings.Repeat("a", 100) + `"`
+
+	t.Run("too large", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/text/small", strings.NewReader(body)))
+
+		g.Eq(w.Code, http.StatusRequestEntityTooLarge)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/text/small", strings.NewReader(`"abc"`)))
+
+		g.Eq(w.Code, http.StatusOK)
+	})
+
+	t.Run("function limit", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/text/upload", strings.NewReader(body)))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(w.Body.String()), "100")
+	})
+}
+
+func TestDefaultEncoding(t *testing.T) {
+	text := Encoding{
+		MimeType: "text/plain",
+		GetDecoder: func(r io.Reader) Decoder {
+			return DecoderFunc(func(v any) error {
+				b, err := io.ReadAll(r)
+				*(v.(*string)) = string(b)
+				return err
+			})
+		},
+		GetEncoder: func(w io.Writer) Encoder {
+			return EncoderFunc(func(v any) error {
+				_, err := fmt.Fprint(w, v)
+				return err
+			})
+		},
+	}
+	fns := []Function{
+		Func("/echo", func(ctx context.Context, s string) (string, error) {
+			return s, nil
+		}),
+	}
+
+	t.Run("json", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithEncodings(text))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`"hello"`)))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithEncodings(text), WithDefaultEncoding("text/plain"))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello")))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "text/plain")
+		g.Eq(w.Body.String(), "hello")
+	})
+
+	t.Run("not registered", func(t *testing.T) {
+		g := got.T(t)
+		_, err := NewHandler(fns, WithDefaultEncoding("text/plain"))
+		g.Eq(err.Error(), "the default encoding 'text/plain' is not registered")
+	})
+}
+
+func TestDecoderWithRequest(t *testing.T) {
+	g := got.T(t)
+
+	// decodes "key=value" lines, with the separator declared by a header
+	lines := Encoding{
+		MimeType: "text/x-lines",
+		GetDecoderWithRequest: func(r *http.Request) Decoder {
+			return DecoderFunc(func(v any) error {
+				_, params, err := mime.ParseMediaType(r.Header.Get("content-type"))
+				if err != nil {
+					return err
+				}
+				if params["charset"] != "utf-8" {
+					return fmt.Errorf("unsupported charset %s", params["charset"])
+				}
+				b, err := io.ReadAll(r.Body)
+				if err != nil {
+					return err
+				}
+				m := map[string]string{}
+				for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+					key, value, _ := strings.Cut(line, r.Header.Get("X-Separator"))
+					m[key] = value
+				}
+				*(v.(*map[string]string)) = m
+				return nil
+			})
+		},
+	}
+
+	h, err := NewHandler([]Function{
+		Func("/config/set", func(ctx context.Context, m map[string]string) (map[string]string, error) {
+			return m, nil
+		}),
+	}, WithEncodings(lines), WithMaxBodyBytes(64))
+	g.Must().Nil(err)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/config/set", strings.NewReader(body))
+		r.Header.Set("content-type", "text/x-lines; charset=utf-8")
+		r.Header.Set("accept", "application/json")
+		r.Header.Set("X-Separator", ":")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post("a:1\nb:2")
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(strings.TrimSpace(w.Body.String()), `{"a":"1","b":"2"}`)
+
+	// the body is limited
+	w = post(strings.Repeat("a:1\n", 20))
+	g.Eq(w.Code, http.StatusRequestEntityTooLarge)
+}
+
+type pageRequest struct {
+	Page  int    `json:"page"`
+	Limit int    `json:"limit" validate:"min=1"`
+	Sort  string `json:"sort"`
+}
+
+func (r *pageRequest) Defaults() {
+	r.Limit = 20
+}
+
+func TestRequestDefaults(t *testing.T) {
+	list := func(ctx context.Context, req pageRequest) (pageRequest, error) {
+		return req, nil
+	}
+	listPtr := func(ctx context.Context, req *pageRequest) (pageRequest, error) {
+		return *req, nil
+	}
+	shared := &pageRequest{Limit: 50}
+	h, err := NewHandler([]Function{
+		Func("/items/list", list, Validate(true)),
+		Func("/items/sorted", list, RequestDefaults(func() pageRequest {
+			return pageRequest{Limit: 50, Sort: "name"}
+		})),
+		Func("/items/pointer", listPtr),
+		Func("/items/shared", listPtr, RequestDefaults(func() *pageRequest { return shared })),
+	})
+	got.T(t).Must().Nil(err)
+
+	call := func(path, body string) (int, pageRequest) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		var res pageRequest
+		json.Unmarshal(w.Body.Bytes(), &res)
+		return w.Code, res
+	}
+
+	t.Run("defaulter", func(t *testing.T) {
+		g := got.T(t)
+		code, res := call("/items/list", `{"page":2}`)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Page: 2, Limit: 20})
+
+		code, res = call("/items/list", ``)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Limit: 20})
+
+		code, _ = call("/items/list", `{"limit":0}`)
+		g.Eq(code, http.StatusBadRequest)
+	})
+
+	t.Run("factory", func(t *testing.T) {
+		g := got.T(t)
+		code, res := call("/items/sorted", `{"sort":"date"}`)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Limit: 50, Sort: "date"})
+	})
+
+	t.Run("pointer defaulter", func(t *testing.T) {
+		g := got.T(t)
+		code, res := call("/items/pointer", ``)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Limit: 20})
+	})
+
+	t.Run("shared factory", func(t *testing.T) {
+		g := got.T(t)
+		code, res := call("/items/shared", `{"limit":5,"sort":"date"}`)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Limit: 5, Sort: "date"})
+		g.Eq(*shared, pageRequest{Limit: 50})
+
+		code, res = call("/items/shared", ``)
+		g.Eq(code, http.StatusOK)
+		g.Eq(res, pageRequest{Limit: 50})
+	})
+
+	t.Run("mismatching factory", func(t *testing.T) {
+		g := got.T(t)
+		_, err := NewHandler([]Function{
+			Func("/items/invalid", list, RequestDefaults(func() string { return "" })),
+		})
+		g.Eq(err.Error(), "/items/invalid: the request defaults of the type string are not of the request type expose.pageRequest")
+	})
+}
+
+type emptyRequest struct {
+	Void
+}
+
+func TestVoidVariants(t *testing.T) {
+	g := got.T(t)
+
+	g.True(isVoid(Void{}))
+	g.True(isVoid(&Void{}))
+	g.True(isVoid((*Void)(nil)))
+	g.True(isVoid(emptyRequest{}))
+	g.True(isVoid(struct{ *Void }{}))
+	g.False(isVoid(struct {
+		Void
+		Name string
+	}{}))
+	g.False(isVoid(nil))
+	g.False(isVoid(""))
+
+	fns := []Function{
+		Func("/ping", func(ctx context.Context, req *Void) (string, error) {
+			return "pong", nil
+		}),
+		Func("/reset", func(ctx context.Context, req emptyRequest) (*Void, error) {
+			return nil, nil
+		}),
+	}
+	h, err := NewHandler(fns)
+	g.Must().Nil(err)
+
+	// the body is not decoded, even when it is not JSON
+	r := httptest.NewRequest(http.MethodPost, "/ping", strings.NewReader("garbage"))
+	r.Header.Set("content-type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(strings.TrimSpace(w.Body.String()), `"pong"`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reset", nil))
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(w.Body.Len(), 0)
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+	g.Nil(spec.Paths.Find("/ping").Post.RequestBody)
+	g.Nil(spec.Paths.Find("/reset").Post.RequestBody)
+}
+
+type benchAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+}
+
+type benchCustomer struct {
+	Name      string         `json:"name"`
+	Addresses []benchAddress `json:"addresses"`
+	Billing   *benchAddress  `json:"billing"`
+	Tags      map[string]string
+}
+
+type benchOrder struct {
+	ID       string          `json:"id"`
+	Customer benchCustomer   `json:"customer"`
+	Lines    []benchCustomer `json:"lines"`
+}
+
+// BenchmarkNewHandler measures the startup of a validating handler, until its spec is served.
+// The spec is reflected only once for the validation, the swagger.json endpoint and the swagger UI.
+func BenchmarkNewHandler(b *testing.B) {
+	var fns []Function
+	for i := 0; i < 50; i++ {
+		fns = append(fns, Func(fmt.Sprintf("/orders/op%d", i), func(ctx context.Context, o benchOrder) (benchCustomer, error) {
+			return o.Customer, nil
+		}, Validate(true)))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h, err := NewHandler(fns, WithSwaggerUI("/swagger-ui"))
+		if err != nil {
+			b.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+		if w.Code != http.StatusOK {
+			b.Fatal(w.Code)
+		}
+	}
+}
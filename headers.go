@@ -0,0 +1,21 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+)
+
+type responseHeadersKey struct{}
+
+// withResponseHeaders returns a copy of `ctx`, that collects the headers set with [SetResponseHeader] in `headers`
+func withResponseHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, responseHeadersKey{}, headers)
+}
+
+// SetResponseHeader sets the header `name` of the response to `value`.
+// Call it in an exposed function with the context, that the [Handler] passed to it. Document the header with [ResponseHeader].
+func SetResponseHeader(ctx context.Context, name, value string) {
+	if headers, ok := ctx.Value(responseHeadersKey{}).(http.Header); ok {
+		headers.Set(name, value)
+	}
+}
@@ -0,0 +1,41 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestResponseHeader(t *testing.T) {
+	g := got.T(t)
+
+	items := []string{"a", "b", "c"}
+
+	h, err := NewHandler([]Function{
+		FuncNullary("/items/list", func(ctx context.Context) ([]string, error) {
+			SetResponseHeader(ctx, "X-Total-Count", strconv.Itoa(len(items)))
+			return items[:2], nil
+		}, ResponseHeader("X-Total-Count", "The number of all items", openapi3.NewIntegerSchema())),
+	})
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/list", nil))
+
+	g.Eq(w.Code, http.StatusOK)
+	g.Eq(w.Header().Get("X-Total-Count"), "3")
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+	header := spec.Paths.Find("/items/list").Post.Responses.Status(200).Value.Headers["X-Total-Count"].Value
+	g.Eq(header.Description, "The number of all items")
+	g.Eq(header.Schema.Value.Type, &openapi3.Types{openapi3.TypeInteger})
+
+	// outside of the handler, the header is ignored
+	SetResponseHeader(context.Background(), "X-Total-Count", "1")
+}
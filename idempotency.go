@@ -0,0 +1,136 @@
+package expose
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header, that carries the idempotency key of a call. See [WithIdempotency].
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentResponse is a response recorded for an idempotency key
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore stores the responses of [Idempotent] functions, keyed by the path of the function and the idempotency key.
+// The keys are scoped to the caller, so they are prefixed with the hash of the caller's identity.
+// The store decides, how long responses are retained, e.g. with the TTL of a redis key.
+type IdempotencyStore interface {
+	// Get returns the response recorded for `key` at `path`. `ok` is false, when there is none.
+	Get(ctx context.Context, path, key string) (res IdempotentResponse, ok bool, err error)
+	// Set records the response for `key` at `path`
+	Set(ctx context.Context, path, key string, res IdempotentResponse) error
+}
+
+// WithIdempotency enables [Idempotent] functions, whose responses are recorded in `store`.
+// When a request carries an `Idempotency-Key` header (see [IdempotencyKeyHeader]) and a response was recorded for the key,
+// the recorded response is replayed, instead of calling the function again. Replayed responses have the header `Idempotent-Replayed: true`.
+//
+// Requests are authenticated (see [WithAuth]) before a response is replayed and the keys are scoped to the caller:
+// the principal of the authentication (see [WithPrincipal]) and the credentials (the `Authorization` and `Cookie` headers).
+//
+// Only the responses of calls, that ran, are recorded. Server errors (5xx) and failures, that a retry might resolve, e.g. invalid requests (400),
+// denied access (401, 403), timeouts (408), conflicts (409), rate limits (429) and canceled requests (499), are not recorded,
+// so that the call can be retried. The body of a repeated request is not compared to the original one and concurrent requests
+// with the same key are not serialized.
+func WithIdempotency(store IdempotencyStore) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.idempotency = store
+	}
+}
+
+// Idempotent opts the function into the idempotency handling of the handler (see [WithIdempotency]).
+// The `Idempotency-Key` header is documented in the spec.
+func Idempotent() FuncOpt {
+	return func(s *functionSettings) {
+		s.idempotent = true
+	}
+}
+
+// idempotent replays the recorded responses of `fn` or records the responses of `next`, when `fn` is [Idempotent]
+func (settings *handlerSettings) idempotent(fn Function, next http.HandlerFunc) http.HandlerFunc {
+	if settings.idempotency == nil || !getFuncSettings(fn).idempotent {
+		return next
+	}
+	store := settings.idempotency
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		ctx := r.Context()
+		key = callerKey(r) + ":" + key
+
+		recorded, ok, err := store.Get(ctx, fn.Path(), key)
+		if err != nil {
+			settings.writeError(ctx, w, nil, fmt.Errorf("failed to read the idempotent response: %w", err))
+			return
+		}
+		if ok {
+			for name, values := range recorded.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(recorded.Status)
+			w.Write(recorded.Body)
+			return
+		}
+
+		rec := newResponseRecorder(w)
+		next(rec, r)
+		if !rec.wroteHeader {
+			rec.WriteHeader(http.StatusOK)
+		}
+		if !recordedStatus(rec.status) {
+			return
+		}
+		// the response is already written, a failure only causes the next request to call the function again
+		_ = store.Set(ctx, fn.Path(), key, IdempotentResponse{Status: rec.status, Header: rec.header, Body: rec.body.Bytes()})
+	}
+}
+
+// recordedStatus reports whether responses with the `status` are recorded, see [WithIdempotency]
+func recordedStatus(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout,
+		http.StatusConflict, http.StatusTooManyRequests, StatusClientClosedRequest:
+		return false
+	}
+	return status < 500
+}
+
+// MemoryIdempotencyStore is an [IdempotencyStore], that keeps the responses in memory for a fixed duration.
+// It holds up to 10000 responses (see [MemoryIdempotencyStore.WithCapacity]) and evicts the least recently used ones,
+// so that evicted keys are no longer replayed. It is suited for single instance deployments and tests.
+type MemoryIdempotencyStore struct {
+	ttl       time.Duration
+	responses *ttlMap[[2]string, IdempotentResponse]
+}
+
+// NewMemoryIdempotencyStore creates a [MemoryIdempotencyStore], that retains responses for `ttl`
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{ttl: ttl, responses: newTTLMap[[2]string, IdempotentResponse](defaultMemoryStoreCapacity)}
+}
+
+// WithCapacity limits the store to `n` responses, the least recently used responses are evicted first. 0 is unlimited.
+func (s *MemoryIdempotencyStore) WithCapacity(n int) *MemoryIdempotencyStore {
+	s.responses.setCapacity(n)
+	return s
+}
+
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, path, key string) (IdempotentResponse, bool, error) {
+	res, ok := s.responses.get([2]string{path, key})
+	return res, ok, nil
+}
+
+func (s *MemoryIdempotencyStore) Set(ctx context.Context, path, key string, res IdempotentResponse) error {
+	s.responses.set([2]string{path, key}, res, s.ttl)
+	return nil
+}
@@ -0,0 +1,195 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	fns := []Function{
+		Func("/payments/charge", func(ctx context.Context, amount int) (int, error) {
+			calls++
+			SetResponseHeader(ctx, "X-Charge", "1")
+			return amount * calls, nil
+		}, Idempotent()),
+		Func("/payments/refund", func(ctx context.Context, amount int) (int, error) {
+			calls++
+			return amount * calls, nil
+		}),
+		FuncNullaryVoid("/payments/fail", func(ctx context.Context) error {
+			calls++
+			return errors.New("unavailable")
+		}, Idempotent()),
+	}
+	store := NewMemoryIdempotencyStore(time.Minute)
+	h, err := NewHandler(fns, WithIdempotency(store))
+	got.T(t).Must().Nil(err)
+
+	call := func(path, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader("10"))
+		if key != "" {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("replays the response", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		first := call("/payments/charge", "a")
+		g.Eq(first.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(first.Body.String()), "10")
+
+		second := call("/payments/charge", "a")
+		g.Eq(second.Code, http.StatusOK)
+		g.Eq(strings.TrimSpace(second.Body.String()), "10")
+		g.Eq(second.Header().Get("X-Charge"), "1")
+		g.Eq(second.Header().Get("Idempotent-Replayed"), "true")
+		g.Eq(calls, 1)
+
+		other := call("/payments/charge", "b")
+		g.Eq(strings.TrimSpace(other.Body.String()), "20")
+		g.Eq(other.Header().Get("Idempotent-Replayed"), "")
+	})
+
+	t.Run("without key", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		call("/payments/charge", "")
+		call("/payments/charge", "")
+		g.Eq(calls, 2)
+	})
+
+	t.Run("not idempotent", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		call("/payments/refund", "a")
+		call("/payments/refund", "a")
+		g.Eq(calls, 2)
+	})
+
+	t.Run("server errors are not recorded", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		g.Eq(call("/payments/fail", "a").Code, http.StatusInternalServerError)
+		g.Eq(call("/payments/fail", "a").Code, http.StatusInternalServerError)
+		g.Eq(calls, 2)
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		op := spec.Paths.Find("/payments/charge").Post
+		g.Len(op.Parameters, 1)
+		g.Eq(op.Parameters[0].Value.Name, IdempotencyKeyHeader)
+		g.Eq(op.Parameters[0].Value.In, "header")
+		g.Len(spec.Paths.Find("/payments/refund").Post.Parameters, 0)
+	})
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	g := got.T(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	store := NewMemoryIdempotencyStore(time.Minute)
+	store.responses.now = func() time.Time { return now }
+
+	g.Must().Nil(store.Set(ctx, "/a", "key", IdempotentResponse{Status: http.StatusOK, Body: []byte("1")}))
+
+	res, ok, err := store.Get(ctx, "/a", "key")
+	g.Must().Nil(err)
+	g.True(ok)
+	g.Eq(string(res.Body), "1")
+
+	_, ok, _ = store.Get(ctx, "/b", "key")
+	g.False(ok)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = store.Get(ctx, "/a", "key")
+	g.False(ok)
+}
+
+func TestIdempotencyAuth(t *testing.T) {
+	calls := 0
+	fns := []Function{
+		Func("/payments/pay", func(ctx context.Context, amount int) (string, error) {
+			calls++
+			if amount == 0 {
+				return "", SetErrStatus(errors.New("payment in progress"), http.StatusConflict)
+			}
+			return "receipt of " + Principal(ctx), nil
+		}, Idempotent()),
+	}
+	h, err := NewHandler(fns, WithIdempotency(NewMemoryIdempotencyStore(time.Minute)),
+		WithAuth(func(ctx context.Context, r *http.Request) (context.Context, error) {
+			user := r.Header.Get("X-User")
+			if user == "" {
+				return nil, errors.New("anonymous")
+			}
+			return WithPrincipal(ctx, user), nil
+		}))
+	got.T(t).Must().Nil(err)
+
+	call := func(user, key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/payments/pay", strings.NewReader(body))
+		r.Header.Set(IdempotencyKeyHeader, key)
+		if user != "" {
+			r.Header.Set("X-User", user)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("scoped to the caller", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		g.Eq(call("alice", "1", "10").Body.String(), "\"receipt of alice\"\n")
+
+		anonymous := call("", "1", "10")
+		g.Eq(anonymous.Code, http.StatusUnauthorized)
+		g.Eq(anonymous.Header().Get("Idempotent-Replayed"), "")
+
+		g.Eq(call("bob", "1", "10").Body.String(), "\"receipt of bob\"\n")
+		replayed := call("alice", "1", "10")
+		g.Eq(replayed.Body.String(), "\"receipt of alice\"\n")
+		g.Eq(replayed.Header().Get("Idempotent-Replayed"), "true")
+		g.Eq(calls, 2)
+	})
+
+	t.Run("failures are retried", func(t *testing.T) {
+		g := got.T(t)
+		calls = 0
+
+		g.Eq(call("", "2", "10").Code, http.StatusUnauthorized)
+		g.Eq(call("alice", "2", "invalid").Code, http.StatusBadRequest)
+		g.Eq(call("alice", "2", "0").Code, http.StatusConflict)
+
+		w := call("alice", "2", "10")
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("Idempotent-Replayed"), "")
+		g.Eq(calls, 2)
+
+		for _, status := range []int{http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests, StatusClientClosedRequest} {
+			g.False(recordedStatus(status))
+		}
+		g.True(recordedStatus(http.StatusUnprocessableEntity))
+	})
+}
@@ -0,0 +1,74 @@
+package expose
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+)
+
+// indexFunction is a function listed on the index page (see [WithIndexPage])
+type indexFunction struct {
+	Path   string
+	Module string
+	Name   string
+	// SwaggerUI links to the operation in the swagger UI, when it is enabled
+	SwaggerUI string
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Exposed functions</title>
+</head>
+<body>
+	<h1>Exposed functions</h1>
+	<table>
+		<thead>
+			<tr><th>Path</th><th>Module</th><th>Name</th><th></th></tr>
+		</thead>
+		<tbody>
+		{{- range .}}
+			<tr>
+				<td><code>{{.Path}}</code></td>
+				<td>{{.Module}}</td>
+				<td>{{.Name}}</td>
+				<td>{{if .SwaggerUI}}<a href="{{.SwaggerUI}}">Swagger UI</a>{{end}}</td>
+			</tr>
+		{{- end}}
+		</tbody>
+	</table>
+</body>
+</html>
+`))
+
+// newIndexHandler renders the index page, that lists the functions `fns`
+func newIndexHandler(settings *handlerSettings, fns []Function) http.HandlerFunc {
+	var functions []indexFunction
+	for _, fn := range fns {
+		f := indexFunction{
+			Path:   path.Join("/", settings.basePath, fn.Path()),
+			Module: fn.Module(),
+			Name:   fn.Name(),
+		}
+		if settings.swaggerUIPath != "" {
+			tag := fn.Module()
+			if tags := getFuncSettings(fn).tags; len(tags) > 0 {
+				tag = tags[0]
+			}
+			// the deep link of the operation in the swagger UI
+			f.SwaggerUI = path.Join("/", settings.basePath, settings.swaggerUIPath) + "/#/" +
+				url.PathEscape(tag) + "/" + url.PathEscape(settings.operationID(fn))
+		}
+		functions = append(functions, f)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("content-type", "text/html; charset=utf-8")
+		if err := indexTemplate.Execute(w, functions); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestIndexPage(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+		FuncNullary("/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}, WithTags("health")),
+	}
+
+	t.Run("root", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithIndexPage("/"), WithSwaggerUI("/swagger-ui"))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "text/html; charset=utf-8")
+		body := w.Body.String()
+		g.True(strings.Contains(body, "<code>/counter/get</code>"))
+		g.True(strings.Contains(body, "<code>/ping</code>"))
+		g.True(strings.Contains(body, `href="/swagger-ui/#/counter/counter%23get"`))
+		g.True(strings.Contains(body, `href="/swagger-ui/#/health/%23ping"`))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		g.Eq(w.Code, http.StatusNotFound)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithIndexPage("/index"))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/index", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.False(strings.Contains(w.Body.String(), "Swagger UI"))
+
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		g.Eq(w.Code, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,18 @@
+package expose
+
+import "context"
+
+// Interceptor wraps the calls of the exposed functions, see [WithInterceptor].
+// It calls the function `fn` with `next`, which decodes the request and applies the function.
+// The context passed to `next` reaches the function, so interceptors can provide call scoped values (see [WithContextValue]).
+type Interceptor func(ctx context.Context, fn Function, next func(ctx context.Context) (any, error)) (any, error)
+
+// intercept calls `apply` with the `interceptors` of the handler. The first interceptor is the outermost.
+func intercept(ctx context.Context, interceptors []Interceptor, fn Function, apply func(ctx context.Context) (any, error)) (any, error) {
+	if len(interceptors) == 0 {
+		return apply(ctx)
+	}
+	return interceptors[0](ctx, fn, func(ctx context.Context) (any, error) {
+		return intercept(ctx, interceptors[1:], fn, apply)
+	})
+}
@@ -0,0 +1,83 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestInterceptor(t *testing.T) {
+	type traceKey struct{}
+
+	var calls []string
+	record := func(name string) Interceptor {
+		return func(ctx context.Context, fn Function, next func(ctx context.Context) (any, error)) (any, error) {
+			calls = append(calls, name+" "+fn.Path())
+			res, err := next(WithContextValue(ctx, traceKey{}))
+			calls = append(calls, name+" done")
+			return res, err
+		}
+	}
+	errRollback := errors.New("rolled back")
+
+	fns := []Function{
+		Func("/echo", func(ctx context.Context, s string) (string, error) {
+			_, ok := FromContext[traceKey](ctx)
+			calls = append(calls, "echo")
+			if !ok {
+				return "", errors.New("missing context value")
+			}
+			return s, nil
+		}),
+		FuncNullary("/fail", func(ctx context.Context) (string, error) {
+			return "", errors.New("failed")
+		}),
+	}
+	h, err := NewHandler(fns, WithInterceptor(record("outer"), record("inner")), WithInterceptor(
+		func(ctx context.Context, fn Function, next func(ctx context.Context) (any, error)) (any, error) {
+			res, err := next(ctx)
+			if err != nil {
+				return nil, errRollback
+			}
+			return res, nil
+		}))
+	got.T(t).Must().Nil(err)
+
+	t.Run("wraps the call", func(t *testing.T) {
+		g := got.T(t)
+		calls = nil
+		r := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(`"hi"`))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "\"hi\"\n")
+		g.Eq(calls, []string{"outer /echo", "inner /echo", "echo", "inner done", "outer done"})
+	})
+
+	t.Run("replaces the error", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fail", nil))
+
+		g.Eq(w.Code, http.StatusInternalServerError)
+		g.Has(w.Body.String(), "rolled back")
+	})
+
+	t.Run("invoke", func(t *testing.T) {
+		g := got.T(t)
+		calls = nil
+		res, err := h.Invoke(context.Background(), "/echo", "hi")
+		g.Nil(err)
+		g.Eq(res, "hi")
+		g.Len(calls, 5)
+
+		_, err = h.Invoke(context.Background(), "/fail", nil)
+		g.Is(err, errRollback)
+	})
+}
@@ -0,0 +1,41 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Invoke calls the function exposed at `path` (or at one of its [Aliases]) in-process, without a HTTP round trip.
+// `req` is encoded as JSON and decoded into the request type of the function, just like the body of a request,
+// so it can be any value with the JSON representation of the request. A nil `req` is an empty body.
+// The request is validated like the requests of the handler (see [Validate]) and the result is returned as is.
+//
+// The HTTP layer of the handler is bypassed: middlewares, authentication, timeouts and the error handling are not applied.
+// Interceptors are applied (see [WithInterceptor]).
+// Unknown paths fail with a [NotFoundError].
+func (h *Handler) Invoke(ctx context.Context, path string, req any) (any, error) {
+	fn, ok := h.byPath[path]
+	if !ok {
+		paths := make([]string, 0, len(h.byPath))
+		for p := range h.byPath {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		return nil, &NotFoundError{Path: path, Suggestions: similarPaths(path, paths)}
+	}
+
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return nil, fmt.Errorf("failed to encode the request of %s: %w", path, err)
+		}
+	}
+
+	return intercept(withFieldNamer(ctx, h.fieldNames), h.interceptors, fn, func(ctx context.Context) (any, error) {
+		return fn.Apply(ctx, JsonEncoding.GetDecoder(bytes.NewReader(body)), h.validationSpec)
+	})
+}
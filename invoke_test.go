@@ -0,0 +1,75 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestInvoke(t *testing.T) {
+	type sumRequest struct {
+		A int `json:"a"`
+		B int `json:"b" validate:"max=10"`
+	}
+
+	fns := []Function{
+		Func("/math/sum", func(ctx context.Context, req sumRequest) (int, error) {
+			return req.A + req.B, nil
+		}, Validate(true), Aliases("/add")),
+		FuncNullary("/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}),
+	}
+	h, err := NewHandler(fns, WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "rejected", http.StatusForbidden)
+		})
+	}))
+	got.T(t).Must().Nil(err)
+
+	t.Run("calls the function", func(t *testing.T) {
+		g := got.T(t)
+		res, err := h.Invoke(context.Background(), "/math/sum", sumRequest{A: 1, B: 2})
+		g.Nil(err)
+		g.Eq(res, 3)
+	})
+
+	t.Run("any value with the json representation of the request", func(t *testing.T) {
+		g := got.T(t)
+		res, err := h.Invoke(context.Background(), "/add", map[string]int{"a": 3, "b": 4})
+		g.Nil(err)
+		g.Eq(res, 7)
+	})
+
+	t.Run("nullary", func(t *testing.T) {
+		g := got.T(t)
+		res, err := h.Invoke(context.Background(), "/ping", nil)
+		g.Nil(err)
+		g.Eq(res, "pong")
+	})
+
+	t.Run("validates the request", func(t *testing.T) {
+		g := got.T(t)
+		_, err := h.Invoke(context.Background(), "/math/sum", sumRequest{A: 1, B: 11})
+		g.NotNil(err)
+		status, _ := GetErrStatus(err)
+		g.Eq(status, http.StatusBadRequest)
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		g := got.T(t)
+		_, err := h.Invoke(context.Background(), "/math/sum", map[string]string{"a": "one"})
+		g.True(errors.Is(err, ErrDecode))
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		g := got.T(t)
+		_, err := h.Invoke(context.Background(), "/math/summ", nil)
+		var notFound *NotFoundError
+		g.Must().True(errors.As(err, &notFound))
+		g.Eq(notFound.Suggestions, []string{"/math/sum"})
+	})
+}
@@ -1,27 +1,42 @@
 package expose
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
-// walkSchema traverses a schema depth first (the order of the visited schemas is not stable).
+// walkSchema traverses a schema depth first. Properties are visited in the order of their names,
+// so that repeated walks of the same schema visit the schemas in the same order.
 // It is just a utility to move all schema definitions of the openapi spec to components/schemas
 // and does not resolve $ref's.
 //
 // When the visitor returns a SchemaRef, the currently visited ref will be replaced with it.
+//
+// Schemas, that contain themselves, are not walked again, so that recursive schemas can be traversed.
 func walkSchema(ref *openapi3.SchemaRef, visitor visitorFn) error {
+	return walkSchemaPath(ref, visitor, map[*openapi3.Schema]bool{})
+}
+
+// walkSchemaPath walks `ref` like [walkSchema]. `path` holds the schemas, that are currently walked.
+func walkSchemaPath(ref *openapi3.SchemaRef, visitor visitorFn, path map[*openapi3.Schema]bool) error {
 
-	if ref.Value == nil {
+	if ref.Value == nil || path[ref.Value] {
 		return nil
 	}
 
 	s := ref.Value
 
+	path[s] = true
+	defer delete(path, s)
+
 	for _, ref := range s.AllOf {
 		if ref.Value != nil {
-			if err := walkSchema(ref, visitor); err != nil {
+			if err := walkSchemaPath(ref, visitor, path); err != nil {
 				return fmt.Errorf("allOf: %w", err)
 			}
 		}
@@ -29,29 +44,41 @@ func walkSchema(ref *openapi3.SchemaRef, visitor visitorFn) error {
 
 	for _, ref := range s.AnyOf {
 		if ref.Value != nil {
-			if err := walkSchema(ref, visitor); err != nil {
-				return fmt.Errorf("allOf: %w", err)
+			if err := walkSchemaPath(ref, visitor, path); err != nil {
+				return fmt.Errorf("anyOf: %w", err)
 			}
 		}
 	}
 
 	for _, ref := range s.OneOf {
 		if ref.Value != nil {
-			if err := walkSchema(ref, visitor); err != nil {
-				return fmt.Errorf("allOf: %w", err)
+			if err := walkSchemaPath(ref, visitor, path); err != nil {
+				return fmt.Errorf("oneOf: %w", err)
 			}
 		}
 	}
 
 	if s.Items != nil {
-		if err := walkSchema(s.Items, visitor); err != nil {
+		if err := walkSchemaPath(s.Items, visitor, path); err != nil {
 			return fmt.Errorf("items: %w", err)
 		}
 	}
 
-	for k, p := range s.Properties {
-		if p.Value != nil {
-			if err := walkSchema(p, visitor); err != nil {
+	if s.AdditionalProperties.Schema != nil {
+		if err := walkSchemaPath(s.AdditionalProperties.Schema, visitor, path); err != nil {
+			return fmt.Errorf("additionalProperties: %w", err)
+		}
+	}
+
+	props := make([]string, 0, len(s.Properties))
+	for k := range s.Properties {
+		props = append(props, k)
+	}
+	slices.Sort(props)
+
+	for _, k := range props {
+		if p := s.Properties[k]; p.Value != nil {
+			if err := walkSchemaPath(p, visitor, path); err != nil {
 				return fmt.Errorf("prop %s: %w", k, err)
 			}
 		}
@@ -69,3 +96,186 @@ func walkSchema(ref *openapi3.SchemaRef, visitor visitorFn) error {
 }
 
 type visitorFn func(s *openapi3.SchemaRef) (*openapi3.SchemaRef, error)
+
+// jsonSchemaDialect is the JSON Schema draft of the documents created by [GenerateJSONSchemas]
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// GenerateJSONSchemas reflects a JSON Schema document for every request and response type of the functions `fns`,
+// e.g. for tools that validate payloads, but do not understand openapi specs.
+// The documents are keyed by their schema identifier (see [WithSchemaIdentifier]), which is also their `$id`.
+//
+// The schemas are reflected just like the schemas of [ReflectSpec]. The referenced schemas are included as `$defs`,
+// and the openapi specific `nullable` is expressed with the `null` type.
+// [Void] and [Blob] results and the bodies of [ProtoBody] functions have no JSON representation and are skipped.
+// The error schema (see [ReflectErrorSchema]) is included as well.
+func GenerateJSONSchemas(fns []Function, opts ...reflectSpecOpt) (map[string]json.RawMessage, error) {
+	fail := func(err error) (map[string]json.RawMessage, error) {
+		return nil, fmt.Errorf("failed to generate json schemas: %w", err)
+	}
+
+	settings := newReflectSettings(opts...)
+	spec, err := ReflectSpec(openapi3.T{}, fns, withSettings(settings))
+	if err != nil {
+		return fail(err)
+	}
+
+	docs := map[string]json.RawMessage{}
+	add := func(v any, ref *openapi3.SchemaRef) error {
+		id := settings.schemaID(reflect.TypeOf(v))
+		if _, ok := docs[id]; ok {
+			return nil
+		}
+		doc, err := newJSONSchemaDocument(id, ref, spec.Components.Schemas)
+		if err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		docs[id] = doc
+		return nil
+	}
+
+	for _, fn := range fns {
+		if getFuncSettings(fn).protoBody {
+			continue
+		}
+		op := operation(spec, fn)
+
+		if !isVoid(fn.Req()) {
+			if err := add(fn.Req(), requestSchema(spec, fn, requestMediaType(fn.Req()))); err != nil {
+				return fail(err)
+			}
+		}
+
+		void := isVoid(fn.Res())
+		_, blob := fn.Res().(Blob)
+		if !void && !blob {
+			if err := add(fn.Res(), op.Responses.Status(200).Value.Content.Get("application/json").Schema); err != nil {
+				return fail(err)
+			}
+		}
+	}
+
+	if settings.errorSchema != nil {
+		ref, err := reflectSchema(settings.errorSchema, spec.Components.Schemas, settings)
+		if err != nil {
+			return fail(err)
+		}
+		if err := add(settings.errorSchema, ref); err != nil {
+			return fail(err)
+		}
+	}
+
+	return docs, nil
+}
+
+// newJSONSchemaDocument converts the openapi schema `ref` into a JSON Schema document with the `$id` `id`.
+// The components `schemas`, that are referenced, become `$defs` of the document.
+func newJSONSchemaDocument(id string, ref *openapi3.SchemaRef, schemas openapi3.Schemas) (json.RawMessage, error) {
+	// the schemas are converted in their JSON representation, which is the same for openapi and JSON Schema
+	toMap := func(ref *openapi3.SchemaRef) (map[string]any, error) {
+		data, err := json.Marshal(ref)
+		if err != nil {
+			return nil, err
+		}
+		var m map[string]any
+		return m, json.Unmarshal(data, &m)
+	}
+
+	root, err := toMap(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	refCount := map[string]int{}
+	refs := func(name string) {
+		if refCount[name] == 0 {
+			pending = append(pending, name)
+		}
+		refCount[name]++
+	}
+
+	// the document describes a named type directly instead of referencing it
+	rootName := ""
+	if r, ok := root["$ref"].(string); ok && len(root) == 1 {
+		rootName = strings.TrimPrefix(r, "#/components/schemas/")
+		pending = append(pending, rootName)
+	} else {
+		root = toJSONSchema(root, refs).(map[string]any)
+	}
+
+	defs := map[string]any{}
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := defs[name]; ok {
+			continue
+		}
+
+		s, ok := schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("schema '%s' not found", name)
+		}
+		def, err := toMap(s)
+		if err != nil {
+			return nil, err
+		}
+		defs[name] = toJSONSchema(def, refs)
+	}
+
+	if rootName != "" {
+		root = defs[rootName].(map[string]any)
+		// recursive types keep their definition, that they refer to
+		if refCount[rootName] == 0 {
+			delete(defs, rootName)
+		}
+	}
+
+	doc := map[string]any{}
+	for k, v := range root {
+		doc[k] = v
+	}
+	doc["$schema"] = jsonSchemaDialect
+	doc["$id"] = id
+	if len(defs) > 0 {
+		doc["$defs"] = defs
+	}
+
+	return json.Marshal(doc)
+}
+
+// toJSONSchema converts the openapi schema `node` in its JSON representation to JSON Schema.
+// `refs` is called with the names of the referenced components.
+func toJSONSchema(node any, refs func(name string)) any {
+	switch n := node.(type) {
+	case []any:
+		for i := range n {
+			n[i] = toJSONSchema(n[i], refs)
+		}
+		return n
+	case map[string]any:
+		for k, v := range n {
+			n[k] = toJSONSchema(v, refs)
+		}
+
+		if ref, ok := n["$ref"].(string); ok && strings.HasPrefix(ref, "#/components/schemas/") {
+			name := strings.TrimPrefix(ref, "#/components/schemas/")
+			n["$ref"] = "#/$defs/" + name
+			refs(name)
+		}
+		// the $id of the reflection only identifies the components
+		if _, ok := n["$id"].(string); ok {
+			delete(n, "$id")
+		}
+		if nullable, _ := n["nullable"].(bool); nullable {
+			delete(n, "nullable")
+			if t, ok := n["type"].(string); ok {
+				n["type"] = []any{t, "null"}
+			} else {
+				return map[string]any{"anyOf": []any{n, map[string]any{"type": "null"}}}
+			}
+		}
+		return n
+	default:
+		return node
+	}
+}
@@ -1,6 +1,8 @@
 package expose
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
 	"testing"
@@ -50,7 +52,7 @@ func TestWalkSchema(t *testing.T) {
 		var actual []string
 		expected := []string{
 			"allOf1", "anyOf1", "allOfNested", "oneOf1", "oneOf2",
-			"nestedProp", "prop2", "item1", "prop1", "root",
+			"item1", "prop1", "nestedProp", "prop2", "root",
 		}
 
 		g.Must().Nil(walkSchema(openapi3.NewSchemaRef("", &schema), func(s *openapi3.SchemaRef) (*openapi3.SchemaRef, error) {
@@ -58,9 +60,6 @@ func TestWalkSchema(t *testing.T) {
 			return nil, nil
 		}))
 
-		slices.Sort(actual)
-		slices.Sort(expected)
-
 		g.Eq(actual, expected)
 	})
 
@@ -118,3 +117,75 @@ func TestWalkSchema(t *testing.T) {
 		}
 	})
 }
+
+type schemaAddress struct {
+	City string `json:"city"`
+}
+
+type schemaCustomer struct {
+	Name    string         `json:"name"`
+	Address *schemaAddress `json:"address"`
+	Nick    *string        `json:"nick"`
+}
+
+func TestGenerateJSONSchemas(t *testing.T) {
+	g := got.T(t)
+
+	docs, err := GenerateJSONSchemas([]Function{
+		Func("/customers/create", func(ctx context.Context, c schemaCustomer) ([]schemaCustomer, error) {
+			return nil, nil
+		}),
+		FuncNullary("/tree/get", func(ctx context.Context) (linkedList, error) {
+			return linkedList{}, nil
+		}),
+		FuncNullaryVoid("/tree/reset", func(ctx context.Context) error {
+			return nil
+		}),
+	}, WithSchemaIdentifier(ShortSchemaIdentifier))
+	g.Must().Nil(err)
+
+	keys := make([]string, 0, len(docs))
+	for k := range docs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	g.Eq(keys, []string{"expose.linkedList", "expose.schemaCustomer", "expose.schemaCustomerList"})
+
+	g.Eq(string(docs["expose.schemaCustomer"]), `{"$defs":{"expose.schemaAddress":{"properties":{"city":{"type":"string"}},"required":["city"],"type":"object"}},`+
+		`"$id":"expose.schemaCustomer","$schema":"https://json-schema.org/draft/2020-12/schema",`+
+		`"properties":{"address":{"anyOf":[{"allOf":[{"$ref":"#/$defs/expose.schemaAddress"}]},{"type":"null"}]},"name":{"type":"string"},"nick":{"type":["string","null"]}},`+
+		`"required":["name"],"type":"object"}`)
+
+	var list map[string]any
+	g.Must().Nil(json.Unmarshal(docs["expose.schemaCustomerList"], &list))
+	g.Eq(list["type"], "array")
+	g.Eq(list["items"], map[string]any{"$ref": "#/$defs/expose.schemaCustomer"})
+	g.Len(list["$defs"], 2)
+
+	// recursive types keep the definition, they refer to
+	var recursive map[string]any
+	g.Must().Nil(json.Unmarshal(docs["expose.linkedList"], &recursive))
+	g.Eq(recursive["$defs"].(map[string]any)["expose.linkedList"].(map[string]any)["type"], "object")
+	g.Eq(recursive["type"], "object")
+}
+
+func TestGenerateJSONSchemasErrorSchema(t *testing.T) {
+	g := got.T(t)
+
+	type apiError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	docs, err := GenerateJSONSchemas([]Function{
+		FuncNullary("/tree/get", func(ctx context.Context) (linkedList, error) {
+			return linkedList{}, nil
+		}),
+	}, WithSchemaIdentifier(ShortSchemaIdentifier), ReflectErrorSchema(apiError{}))
+	g.Must().Nil(err)
+
+	var doc map[string]any
+	g.Must().Nil(json.Unmarshal(docs["expose.apiError"], &doc))
+	g.Eq(doc["type"], "object")
+	g.Eq(doc["properties"], map[string]any{"code": map[string]any{"type": "string"}, "message": map[string]any{"type": "string"}})
+}
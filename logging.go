@@ -0,0 +1,92 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// LogEntry describes a call to an exposed function. See [WithLogger].
+type LogEntry struct {
+	// Function is the called function
+	Function Function
+	// Duration is the time spent in decoding the request and applying the function
+	Duration time.Duration
+	// RequestSize is the number of bytes, that were read from the request body
+	RequestSize int64
+	// Err is the error returned by the function or the decoder
+	Err error
+	// Outcome classifies the call, e.g. to tell canceled requests apart from failures in metrics
+	Outcome Outcome
+}
+
+// Outcome classifies a call to an exposed function, see [LogEntry]
+type Outcome int
+
+const (
+	// OutcomeSuccess is a call, that returned without error
+	OutcomeSuccess Outcome = iota
+	// OutcomeError is a call, that failed
+	OutcomeError
+	// OutcomeCanceled is a call, whose request was canceled by the client, see [ErrCanceled]
+	OutcomeCanceled
+	// OutcomeTimeout is a call, that exceeded its deadline, see [ErrTimeout]
+	OutcomeTimeout
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeError:
+		return "error"
+	case OutcomeCanceled:
+		return "canceled"
+	case OutcomeTimeout:
+		return "timeout"
+	default:
+		return fmt.Sprint("Outcome(", int(o), ")")
+	}
+}
+
+// outcome classifies the call, that returned `err`
+func outcome(err error) Outcome {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, ErrCanceled):
+		return OutcomeCanceled
+	case errors.Is(err, ErrTimeout):
+		return OutcomeTimeout
+	default:
+		return OutcomeError
+	}
+}
+
+// LogFunc is called after every call to an exposed function. See [WithLogger].
+type LogFunc func(ctx context.Context, entry LogEntry)
+
+// logCall passes the call of `fn`, that started at `start` and read `requestSize` bytes of the body, to the logger
+func (settings *handlerSettings) logCall(ctx context.Context, fn Function, start time.Time, requestSize int64, err error) {
+	settings.logger(ctx, LogEntry{
+		Function:    fn,
+		Duration:    time.Since(start),
+		RequestSize: requestSize,
+		Err:         err,
+		Outcome:     outcome(err),
+	})
+}
+
+// countingReader counts the bytes read from the underlying reader
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,46 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestLogger(t *testing.T) {
+	g := got.T(t)
+
+	errNegative := errors.New("negative delta")
+
+	var entries []LogEntry
+	h, err := NewHandler([]Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			if delta < 0 {
+				return 0, errNegative
+			}
+			return delta, nil
+		}),
+	}, WithLogger(func(ctx context.Context, entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+	g.Must().Nil(err)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counter/inc", strings.NewReader("42")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counter/inc", strings.NewReader("-1")))
+
+	g.Must().Len(entries, 2)
+
+	g.Eq(entries[0].Function.Path(), "/counter/inc")
+	g.Eq(entries[0].RequestSize, int64(2))
+	g.Nil(entries[0].Err)
+	g.Eq(entries[0].Outcome, OutcomeSuccess)
+	g.Gt(entries[0].Duration, 0)
+
+	g.Eq(entries[1].RequestSize, int64(2))
+	g.Is(entries[1].Err, errNegative)
+	g.Eq(entries[1].Outcome, OutcomeError)
+}
@@ -0,0 +1,161 @@
+package expose
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Methods sets the HTTP methods, that the function answers. Default: POST
+//
+// Requests of all methods but GET are decoded from their body, like POST requests.
+// GET requests are decoded from the query (see [FormEncoding] for the field names), so the request must be a struct or [Void].
+// Its fields are documented as query parameters.
+//
+// Every method is documented as an operation. The operation of POST, or of the first method when the function does not answer POST,
+// has the operationId of the function (see [WithOperationID]). The operationIds of the other methods are suffixed with the method,
+// e.g. 'counter#get_put'.
+func Methods(methods ...string) FuncOpt {
+	return func(s *functionSettings) {
+		s.methods = nil
+		for _, method := range methods {
+			s.methods = append(s.methods, strings.ToUpper(method))
+		}
+	}
+}
+
+// functionMethods returns the HTTP methods, that `fn` answers, see [Methods]
+func functionMethods(fn Function) []string {
+	if methods := getFuncSettings(fn).methods; len(methods) > 0 {
+		return methods
+	}
+	return []string{http.MethodPost}
+}
+
+// checkMethods fails, when a function answers GET requests, that can not be decoded from the query
+func checkMethods(fns []Function) error {
+	for _, fn := range fns {
+		if !slices.Contains(functionMethods(fn), http.MethodGet) || isVoid(fn.Req()) {
+			continue
+		}
+		t := reflect.TypeOf(fn.Req())
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("%s: GET requests are decoded from the query, but the request %T is not a struct", fn.Path(), fn.Req())
+		}
+	}
+	return nil
+}
+
+// addOperations adds the operation `op` of `fn` for each of its methods.
+// The request body of GET operations is replaced by query parameters.
+func addOperations(root *openapi3.T, fn Function, op *openapi3.Operation, schemas openapi3.Schemas) {
+	methods := functionMethods(fn)
+	primary := methods[0]
+	if slices.Contains(methods, http.MethodPost) {
+		primary = http.MethodPost
+	}
+
+	for _, method := range methods {
+		methodOp := *op
+		if method != primary {
+			methodOp.OperationID = fmt.Sprint(op.OperationID, "_", strings.ToLower(method))
+		}
+		if method == http.MethodGet && op.RequestBody != nil {
+			methodOp.RequestBody = nil
+			methodOp.Parameters = append(slices.Clone(op.Parameters), queryParameters(requestBodySchema(op), schemas)...)
+		}
+		root.AddOperation(fn.Path(), method, &methodOp)
+	}
+}
+
+// queryParameters describes the properties of the request schema `ref` as query parameters, ordered by their name
+func queryParameters(ref *openapi3.SchemaRef, schemas openapi3.Schemas) openapi3.Parameters {
+	if ref == nil {
+		return nil
+	}
+	if ref.Ref != "" {
+		if component, ok := schemas[strings.TrimPrefix(ref.Ref, "#/components/schemas/")]; ok {
+			ref = component
+		}
+	}
+	if ref.Value == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(ref.Value.Properties))
+	for name := range ref.Value.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make(openapi3.Parameters, 0, len(names))
+	for _, name := range names {
+		params = append(params, &openapi3.ParameterRef{Value: &openapi3.Parameter{
+			In:       openapi3.ParameterInQuery,
+			Name:     name,
+			Schema:   ref.Value.Properties[name],
+			Required: slices.Contains(ref.Value.Required, name),
+		}})
+	}
+	return params
+}
+
+// requestBodySchema returns the schema of the request body of `op`, preferably of its JSON content
+func requestBodySchema(op *openapi3.Operation) *openapi3.SchemaRef {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	content := op.RequestBody.Value.Content
+	if mediaType := content.Get("application/json"); mediaType != nil {
+		return mediaType.Schema
+	}
+	for _, mediaType := range content {
+		return mediaType.Schema
+	}
+	return nil
+}
+
+// operation returns the operation of the primary method of `fn` in the `spec`, see [Methods]
+func operation(spec openapi3.T, fn Function) *openapi3.Operation {
+	item := spec.Paths.Find(fn.Path())
+	if item == nil {
+		return nil
+	}
+	methods := functionMethods(fn)
+	if slices.Contains(methods, http.MethodPost) {
+		return item.Post
+	}
+	return item.GetOperation(methods[0])
+}
+
+// requestSchema returns the schema of the requests of `fn` in the `mediaType`.
+// The schema of functions, that only answer GET requests, is composed of their query parameters.
+func requestSchema(spec openapi3.T, fn Function, mediaType string) *openapi3.SchemaRef {
+	op := operation(spec, fn)
+	if op.RequestBody != nil {
+		if content := op.RequestBody.Value.Content.Get(mediaType); content != nil {
+			return content.Schema
+		}
+		return nil
+	}
+
+	schema := openapi3.NewObjectSchema()
+	for _, param := range op.Parameters {
+		if param.Value == nil || param.Value.In != openapi3.ParameterInQuery {
+			continue
+		}
+		schema.WithPropertyRef(param.Value.Name, param.Value.Schema)
+		if param.Value.Required {
+			schema.Required = append(schema.Required, param.Value.Name)
+		}
+	}
+	return openapi3.NewSchemaRef("", schema)
+}
@@ -0,0 +1,116 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestMethods(t *testing.T) {
+	type searchRequest struct {
+		Query string   `json:"q"`
+		Tags  []string `json:"tags,omitempty"`
+		Limit int      `json:"limit,omitempty" validate:"max=100"`
+	}
+
+	fns := []Function{
+		Func("/search", func(ctx context.Context, req searchRequest) ([]string, error) {
+			return append([]string{req.Query}, req.Tags...), nil
+		}, Methods("get", "post"), Validate(true)),
+		Func("/users/update", func(ctx context.Context, name string) (string, error) {
+			return "updated " + name, nil
+		}, Methods(http.MethodPut, http.MethodPatch)),
+		FuncNullary("/status", func(ctx context.Context) (string, error) {
+			return "ok", nil
+		}, Methods(http.MethodGet)),
+		FuncNullary("/post", func(ctx context.Context) (string, error) {
+			return "posted", nil
+		}),
+	}
+	h, err := NewHandler(fns)
+	got.T(t).Must().Nil(err)
+
+	call := func(method, target, body string) *httptest.ResponseRecorder {
+		var r *http.Request
+		if body == "" {
+			r = httptest.NewRequest(method, target, nil)
+		} else {
+			r = httptest.NewRequest(method, target, strings.NewReader(body))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("GET decodes the query", func(t *testing.T) {
+		g := got.T(t)
+		w := call(http.MethodGet, "/search?q=go&tags=a&tags=b", "")
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "[\"go\",\"a\",\"b\"]\n")
+
+		w = call(http.MethodPost, "/search", `{"q":"go"}`)
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Body.String(), "[\"go\"]\n")
+
+		g.Eq(call(http.MethodGet, "/status", "").Body.String(), "\"ok\"\n")
+	})
+
+	t.Run("GET requests are validated", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call(http.MethodGet, "/search?q=go&limit=101", "").Code, http.StatusBadRequest)
+		g.Eq(call(http.MethodGet, "/search?q=go&limit=many", "").Code, http.StatusBadRequest)
+	})
+
+	t.Run("other methods decode the body", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call(http.MethodPut, "/users/update", `"ada"`).Body.String(), "\"updated ada\"\n")
+		g.Eq(call(http.MethodPatch, "/users/update", `"ada"`).Body.String(), "\"updated ada\"\n")
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		g := got.T(t)
+		w := call(http.MethodPost, "/users/update", `"ada"`)
+		g.Eq(w.Code, http.StatusMethodNotAllowed)
+		g.Eq(w.Header().Get("Allow"), "PUT, PATCH")
+
+		w = call(http.MethodGet, "/post", "")
+		g.Eq(w.Code, http.StatusMethodNotAllowed)
+		g.Eq(w.Header().Get("Allow"), "POST")
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		search := spec.Paths.Find("/search")
+		g.Eq(search.Post.OperationID, "#search")
+		g.NotNil(search.Post.RequestBody)
+		g.Eq(search.Get.OperationID, "#search_get")
+		g.Nil(search.Get.RequestBody)
+		g.Must().Len(search.Get.Parameters, 3)
+		q := search.Get.Parameters.GetByInAndName("query", "q")
+		g.Must().NotNil(q)
+		g.True(q.Required)
+		g.False(search.Get.Parameters.GetByInAndName("query", "tags").Required)
+
+		update := spec.Paths.Find("/users/update")
+		g.Nil(update.Post)
+		g.Eq(update.Put.OperationID, "users#update")
+		g.Eq(update.Patch.OperationID, "users#update_patch")
+
+		g.Eq(spec.Paths.Find("/status").Get.OperationID, "#status")
+	})
+
+	t.Run("GET requests must be structs", func(t *testing.T) {
+		g := got.T(t)
+		_, err := NewHandler([]Function{
+			Func("/echo", func(ctx context.Context, s string) (string, error) { return s, nil }, Methods(http.MethodGet)),
+		})
+		g.Has(err.Error(), "/echo: GET requests are decoded from the query")
+	})
+}
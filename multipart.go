@@ -0,0 +1,143 @@
+package expose
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+const multipartFormData = "multipart/form-data"
+
+// defaultMaxMultipartMemory is the default of [WithMaxMultipartMemory], just like in [http.Request.ParseMultipartForm]
+const defaultMaxMultipartMemory = 32 << 20
+
+// FileUpload is a file uploaded with a 'multipart/form-data' request.
+//
+// Request structs with FileUpload fields (or []FileUpload for multiple files) are documented with a 'multipart/form-data' body,
+// in which the files are `format: binary` properties. The handler maps the file parts to the FileUpload fields and
+// the other parts to the remaining fields, like [FormEncoding]. The files are closed and removed, when the function returns.
+// Since the spec names the parts after the `json` tags of the fields, name them with `json` rather than `form` tags.
+type FileUpload struct {
+	// Filename is the name of the file on the client
+	Filename string
+	// ContentType is the content-type of the file part
+	ContentType string
+	// Size is the size of the file in bytes
+	Size int64
+	// File is the content of the file
+	File multipart.File
+}
+
+// MarshalJSON describes the upload by its filename, e.g. for the validation of the request (see [Validate])
+func (f FileUpload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Filename)
+}
+
+func (FileUpload) JSONSchema(gen *openapi3gen.Generator, schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+	return openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithFormat("binary")), nil
+}
+
+var fileUploadType = reflect.TypeOf(FileUpload{})
+
+// hasFileUpload reports whether the struct `t` has [FileUpload] fields
+func hasFileUpload(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && hasFileUpload(f.Type) {
+			return true
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice {
+			ft = ft.Elem()
+		}
+		if ft == fileUploadType {
+			return true
+		}
+	}
+	return false
+}
+
+// requestMediaType is the media type of the request body of a function with the request `req`
+func requestMediaType(req any) string {
+	if hasFileUpload(reflect.TypeOf(req)) {
+		return multipartFormData
+	}
+	return "application/json"
+}
+
+// WithMaxMultipartMemory limits the memory, that a 'multipart/form-data' request occupies, to `n` bytes.
+// Larger files are stored in temporary files. Default: 32 MB
+func WithMaxMultipartMemory(n int64) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.maxMultipartMemory = n
+	}
+}
+
+// multipartDecoder decodes a 'multipart/form-data' body into a request struct. See [FileUpload].
+type multipartDecoder struct {
+	body      io.Reader
+	boundary  string
+	maxMemory int64
+
+	form  *multipart.Form
+	files []multipart.File
+}
+
+func (d *multipartDecoder) Decode(v any) error {
+	if d.boundary == "" {
+		return errors.New("invalid multipart form: missing boundary")
+	}
+
+	form, err := multipart.NewReader(d.body, d.boundary).ReadForm(d.maxMemory)
+	if err != nil {
+		return fmt.Errorf("invalid multipart form: %w", err)
+	}
+	d.form = form
+
+	m := formMap(form.Value)
+	for name, headers := range form.File {
+		uploads := make([]FileUpload, 0, len(headers))
+		for _, h := range headers {
+			f, err := h.Open()
+			if err != nil {
+				return err
+			}
+			d.files = append(d.files, f)
+			uploads = append(uploads, FileUpload{
+				Filename:    h.Filename,
+				ContentType: h.Header.Get("Content-Type"),
+				Size:        h.Size,
+				File:        f,
+			})
+		}
+		if len(uploads) == 1 {
+			m[name] = uploads[0]
+			continue
+		}
+		m[name] = uploads
+	}
+
+	return decodeFormMap(m, v)
+}
+
+// close closes the uploaded files and removes their temporary files
+func (d *multipartDecoder) close() {
+	for _, f := range d.files {
+		f.Close()
+	}
+	if d.form != nil {
+		d.form.RemoveAll()
+	}
+}
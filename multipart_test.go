@@ -0,0 +1,177 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+type avatarUpload struct {
+	UserID      int          `json:"userId"`
+	Avatar      FileUpload   `json:"avatar"`
+	Attachments []FileUpload `json:"attachments,omitempty"`
+}
+
+type avatarResult struct {
+	UserID      int
+	Filename    string
+	ContentType string
+	Content     string
+	Attachments []string
+}
+
+func TestFileUpload(t *testing.T) {
+	h, err := NewHandler([]Function{
+		Func("/users/avatar", func(ctx context.Context, req avatarUpload) (avatarResult, error) {
+			content, err := io.ReadAll(req.Avatar.File)
+			if err != nil {
+				return avatarResult{}, err
+			}
+			res := avatarResult{
+				UserID:      req.UserID,
+				Filename:    req.Avatar.Filename,
+				ContentType: req.Avatar.ContentType,
+				Content:     string(content),
+			}
+			for _, a := range req.Attachments {
+				res.Attachments = append(res.Attachments, a.Filename)
+			}
+			return res, nil
+		}, Validate(true)),
+	})
+	got.T(t).Must().Nil(err)
+
+	t.Run("upload", func(t *testing.T) {
+		g := got.T(t)
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		g.Must().Nil(mw.WriteField("userId", "7"))
+		part, err := mw.CreateFormFile("avatar", "me.png")
+		g.Must().Nil(err)
+		part.Write([]byte("png"))
+		for _, name := range []string{"a.txt", "b.txt"} {
+			part, err := mw.CreateFormFile("attachments", name)
+			g.Must().Nil(err)
+			part.Write([]byte(name))
+		}
+		g.Must().Nil(mw.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/users/avatar", &body)
+		r.Header.Set("content-type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+
+		var res avatarResult
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &res))
+		g.Eq(res, avatarResult{
+			UserID:      7,
+			Filename:    "me.png",
+			ContentType: "application/octet-stream",
+			Content:     "png",
+			Attachments: []string{"a.txt", "b.txt"},
+		})
+	})
+
+	t.Run("missing boundary", func(t *testing.T) {
+		g := got.T(t)
+
+		r := httptest.NewRequest(http.MethodPost, "/users/avatar", bytes.NewBufferString("userId=1"))
+		r.Header.Set("content-type", "multipart/form-data")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusBadRequest)
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		content := spec.Paths.Find("/users/avatar").Post.RequestBody.Value.Content
+		g.Nil(content.Get("application/json"))
+		ref := content.Get("multipart/form-data").Schema
+		schema := spec.Components.Schemas[ref.Ref[len("#/components/schemas/"):]].Value
+		g.Eq(schema.Properties["avatar"].Value.Type.Is("string"), true)
+		g.Eq(schema.Properties["avatar"].Value.Format, "binary")
+		g.Eq(schema.Properties["attachments"].Value.Items.Value.Format, "binary")
+	})
+}
+
+func TestMaxMultipartMemory(t *testing.T) {
+	const limit = 1024
+
+	type upload struct {
+		Avatar FileUpload `json:"avatar"`
+	}
+	type result struct {
+		Size   int64
+		OnDisk bool
+	}
+
+	var tempFile string
+	h, err := NewHandler([]Function{
+		Func("/upload", func(ctx context.Context, req upload) (result, error) {
+			content, err := io.ReadAll(req.Avatar.File)
+			if err != nil {
+				return result{}, err
+			}
+			f, onDisk := req.Avatar.File.(*os.File)
+			if onDisk {
+				tempFile = f.Name()
+			}
+			return result{Size: int64(len(content)), OnDisk: onDisk}, nil
+		}),
+	}, WithMaxMultipartMemory(limit))
+	got.T(t).Must().Nil(err)
+
+	send := func(g got.G, size int) result {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("avatar", "me.png")
+		g.Must().Nil(err)
+		part.Write(bytes.Repeat([]byte("x"), size))
+		g.Must().Nil(mw.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		r.Header.Set("content-type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		g.Must().Eq(w.Code, http.StatusOK)
+
+		var res result
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &res))
+		return res
+	}
+
+	t.Run("at the limit", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(send(g, limit), result{Size: limit, OnDisk: false})
+	})
+
+	t.Run("beyond the limit", func(t *testing.T) {
+		g := got.T(t)
+		tempFile = ""
+		g.Eq(send(g, limit+1), result{Size: limit + 1, OnDisk: true})
+
+		// the temporary file is removed after the call
+		g.Neq(tempFile, "")
+		_, err := os.Stat(tempFile)
+		g.True(errors.Is(err, fs.ErrNotExist))
+	})
+}
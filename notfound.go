@@ -0,0 +1,106 @@
+package expose
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// NotFoundError is the error response for paths, that no function is exposed at.
+// It lists the exposed paths, that are similar to the requested path, e.g. for typos.
+type NotFoundError struct {
+	Path string `mapstructure:"path"`
+	// Suggestions are the similar exposed paths
+	Suggestions []string `mapstructure:"suggestions,omitempty"`
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("no function is exposed at '%s'", e.Path)
+}
+
+func (e *NotFoundError) Status() int {
+	return http.StatusNotFound
+}
+
+// maxSuggestions limits the suggested paths of a [NotFoundError]
+const maxSuggestions = 5
+
+// newNotFoundHandler responds with a [NotFoundError] through the default encoding (see [WithDefaultEncoding]),
+// unless a custom handler is registered with [WithNotFoundHandler]
+func newNotFoundHandler(settings *handlerSettings, fns []Function) http.HandlerFunc {
+	if settings.notFoundHandler != nil {
+		return settings.notFoundHandler.ServeHTTP
+	}
+
+	var paths []string
+	for _, fn := range fns {
+		paths = append(paths, fn.Path())
+		paths = append(paths, getFuncSettings(fn).aliases...)
+	}
+
+	enc := settings.encoding[settings.defaultEncoding]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := &NotFoundError{Path: r.URL.Path, Suggestions: similarPaths(r.URL.Path, paths)}
+		settings.writeError(r.Context(), w, &enc, err)
+	}
+}
+
+// similarPaths returns the paths, that are below `p` or only a few edits away from it. The closest paths come first.
+func similarPaths(p string, paths []string) []string {
+	type candidate struct {
+		path     string
+		distance int
+	}
+
+	maxDistance := max(2, len(p)/4)
+	var candidates []candidate
+	for _, candidatePath := range paths {
+		if p != "/" && strings.HasPrefix(candidatePath, strings.TrimSuffix(p, "/")+"/") {
+			candidates = append(candidates, candidate{candidatePath, len(candidatePath) - len(p)})
+			continue
+		}
+		if d := editDistance(p, candidatePath); d <= maxDistance {
+			candidates = append(candidates, candidate{candidatePath, d})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].path < candidates[j].path
+	})
+
+	var similar []string
+	for _, c := range candidates {
+		if len(similar) == maxSuggestions {
+			break
+		}
+		similar = append(similar, c.path)
+	}
+	return similar
+}
+
+// editDistance is the levenshtein distance of `a` and `b`
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
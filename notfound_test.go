@@ -0,0 +1,71 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestNotFound(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/inc", func(ctx context.Context) (int, error) { return 1, nil }),
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) { return 1, nil }),
+		FuncNullary("/users/list", func(ctx context.Context) ([]string, error) { return nil, nil }),
+	}
+
+	t.Run("json error", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/counter/icn", nil))
+
+		g.Eq(w.Code, http.StatusNotFound)
+		g.Eq(w.Header().Get("content-type"), "application/json")
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["message"], "no function is exposed at '/counter/icn'")
+		g.Eq(body["path"], "/counter/icn")
+		g.Eq(body["suggestions"], []any{"/counter/inc", "/counter/get"})
+	})
+
+	t.Run("paths below", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/counter", nil))
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["suggestions"], []any{"/counter/get", "/counter/inc"})
+	})
+
+	t.Run("custom handler", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/unknown", nil))
+		g.Eq(w.Code, http.StatusTeapot)
+	})
+}
+
+func TestSimilarPaths(t *testing.T) {
+	g := got.T(t)
+
+	paths := []string{"/counter/inc", "/counter/get", "/users/list"}
+	g.Eq(similarPaths("/users/lst", paths), []string{"/users/list"})
+	g.Len(similarPaths("/orders/create", paths), 0)
+	g.Eq(editDistance("kitten", "sitting"), 3)
+}
@@ -3,17 +3,37 @@ package expose
 import (
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// WithSwaggerUI, adds a SwaggerUI handler at the provided `path`
+// WithSwaggerUI, adds a SwaggerUI handler at the provided `path`.
+// The UI loads the spec from the swagger.json endpoint (see [WithSwaggerJSONPath]).
 func WithSwaggerUI(path string) HandlerOption {
 	return func(settings *handlerSettings) {
 		settings.swaggerUIPath = path
 	}
 }
 
+// WithIndexPage serves a HTML page at `path`, that lists all exposed functions.
+// When the swagger UI is enabled (see [WithSwaggerUI]), the functions link to their operations.
+func WithIndexPage(path string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.indexPath = path
+	}
+}
+
+// WithNotFoundHandler responds to requests of paths, that no function is exposed at, with `h`.
+// By default, the handler responds with a [NotFoundError] in the default encoding (see [WithDefaultEncoding]),
+// which suggests similar paths.
+func WithNotFoundHandler(h http.Handler) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.notFoundHandler = h
+	}
+}
+
 // WithErrorHandler registers a custom [ErrorHandler]
 func WithErrorHandler(h ErrorHandler) HandlerOption {
 	return func(settings *handlerSettings) {
@@ -21,6 +41,148 @@ func WithErrorHandler(h ErrorHandler) HandlerOption {
 	}
 }
 
+// WithErrorFormatter replaces the body of the error responses with the result of `format`, e.g. to hide the fields
+// of internal errors in production. By default, the body holds the exported fields of the error, its message and its code.
+// The status (see [WithDefaultErrorStatus]) and the headers, e.g. Retry-After, are still derived from the error, and a custom
+// [ErrorHandler] still takes precedence. Errors, that are written as plain text, are not formatted.
+// Document the shape of the body with [WithErrorSchema].
+func WithErrorFormatter(format ErrorFormatter) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.errorFormatter = format
+	}
+}
+
+// WithAuth registers an [AuthFunc], that authenticates every request to an exposed function
+func WithAuth(auth AuthFunc) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.auth = auth
+	}
+}
+
+// WithTracing creates a span for every call of an exposed function, named after its operationId (see [WithOperationID]).
+// The decode, apply and encode phases are recorded as child spans and errors mark the span as failed.
+// Spans continue the trace, that is propagated by the request headers (see [go.opentelemetry.io/otel.SetTextMapPropagator]).
+func WithTracing(tracer trace.Tracer) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.tracer = tracer
+	}
+}
+
+// WithLogger registers a [LogFunc], that is called after every call to an exposed function.
+// Unlike a logging [Middleware], it knows which function was called and whether it failed.
+func WithLogger(logger LogFunc) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.logger = logger
+	}
+}
+
+// WithDebugEndpoint serves the effective configuration of the handler (functions, encodings, middlewares, validation, timeouts and limits...)
+// as JSON at the provided `path`. The endpoint is disabled by default, make sure to not expose it publicly.
+func WithDebugEndpoint(path string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.debugPath = path
+	}
+}
+
+// WithDefaultErrorStatus overrides the status codes of error responses: `appStatus` for [ErrApplication]s (default: 422)
+// and `internalStatus` for any other error (default: 500). Errors, that carry their own status (see [WithStatus]),
+// and a custom [ErrorHandler] take precedence.
+func WithDefaultErrorStatus(appStatus, internalStatus int) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.appErrorStatus = appStatus
+		settings.internalErrorStatus = internalStatus
+	}
+}
+
+// WithErrorCodeStatus maps error codes (see [SetErrCode]) to the status of their error response,
+// e.g. `{"not_found": 404}`. The statuses are documented as possible responses of every operation.
+func WithErrorCodeStatus(statusByCode map[string]int) HandlerOption {
+	return func(settings *handlerSettings) {
+		if settings.errorCodeStatus == nil {
+			settings.errorCodeStatus = map[string]int{}
+		}
+		for code, status := range statusByCode {
+			settings.errorCodeStatus[code] = status
+		}
+	}
+}
+
+// WithErrorSchema documents the shape of the error responses. The type of `v` is reflected into the
+// components/schemas and used as `4XX` and `5XX` response of every operation.
+// Make sure it matches the error body of the handler or of your [ErrorHandler]. See [ReflectErrorSchema].
+func WithErrorSchema(v any) HandlerOption {
+	return WithReflection(ReflectErrorSchema(v))
+}
+
+// WithResponseValidation validates the results of all functions against their response schema.
+// Results, that do not conform, are answered with 500 Internal Server Error instead.
+// Every response is encoded an additional time for the validation, so enable it in testing or staging environments only.
+func WithResponseValidation(validate bool) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.validateResponses = validate
+	}
+}
+
+// WithMaxBodyBytes limits the size of request bodies to `n` bytes. Larger requests are rejected with 413 Request Entity Too Large.
+// Functions can override the limit with [MaxBodyBytes].
+func WithMaxBodyBytes(n int64) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.maxBodyBytes = n
+	}
+}
+
+// WithTimeout limits the duration of the calls of the exposed functions. The context of the function is canceled after the `timeout`,
+// and when the function fails afterwards, the handler responds with 504 Gateway Timeout (see [ErrTimeout]). Default: no timeout
+func WithTimeout(timeout time.Duration) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.timeout = timeout
+	}
+}
+
+// WithHeaderTimeout lets clients set the timeout of a call in milliseconds with the request `header`, e.g. 'X-Timeout-Ms'.
+// The timeout is clamped to `max` (no limit when `max` is 0). Without a valid header, the timeout of the handler applies (see [WithTimeout]).
+// Calls, that exceed the timeout of the client, do not count as failures of a [CircuitBreaker], unless the timeout was clamped.
+func WithHeaderTimeout(header string, max time.Duration) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.timeoutHeader = header
+		settings.maxHeaderTimeout = max
+	}
+}
+
+// WithMaxConcurrency limits the number of concurrent calls of the exposed functions to `n`.
+// Calls beyond the limit are rejected with 503 Service Unavailable and a `Retry-After` header (see [ErrConcurrencyLimit]).
+func WithMaxConcurrency(n int) HandlerOption {
+	return func(settings *handlerSettings) {
+		if n > 0 {
+			settings.concurrency = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithFieldNames transforms the names of struct fields without a `json` name tag, e.g. with [CamelCase].
+// The names are transformed consistently in the reflected spec (properties and required properties)
+// and on the wire: values of the JSON encodings ('application/json' and '+json' suffixes) are encoded and decoded with the
+// transformed names, just like the request and response validation.
+//
+// Types with their own JSON representation ([json.Marshaler], [json.Unmarshaler] and [encoding.TextMarshaler]) are not transformed.
+func WithFieldNames(transform func(name string) string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.fieldTransform = transform
+	}
+}
+
+// WithInt64AsString encodes int64 and uint64 values as JSON strings, e.g. `"9007199254740993"`, since JavaScript clients lose
+// the precision of numbers beyond 2^53. Requests may send the values as strings or as numbers.
+// The schemas of the values are strings with the format 'int64' or 'uint64'.
+// Like [WithFieldNames], it applies to the JSON encodings, the request and response validation and the reflected spec.
+//
+// Types with their own JSON representation ([json.Marshaler], [json.Unmarshaler] and [encoding.TextMarshaler]) are not converted.
+func WithInt64AsString(enabled bool) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.int64Strings = enabled
+	}
+}
+
 // WithSwaggerJSONPath overrides the default path (/swagger.json), where the spec is served
 func WithSwaggerJSONPath(path string) HandlerOption {
 	return func(settings *handlerSettings) {
@@ -28,6 +190,40 @@ func WithSwaggerJSONPath(path string) HandlerOption {
 	}
 }
 
+// WithSpecCacheControl sets the Cache-Control header of the served spec. The spec carries an ETag,
+// so clients can revalidate it cheaply with If-None-Match. Default: no-cache
+func WithSpecCacheControl(cacheControl string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.specCacheControl = cacheControl
+	}
+}
+
+// WithSpec serves `spec` instead of reflecting the spec of the exposed functions.
+// Use it with a spec created by [BuildSpec], e.g. to add external docs or webhooks.
+func WithSpec(spec openapi3.T) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.spec = &spec
+	}
+}
+
+// WithSpecPostProcessor transforms the reflected spec with the `processors`, before it is served, e.g. to add global parameters,
+// to sort the tags or to remove internal paths. The processors run in order, also across options, and the first error fails
+// the reflection. They also process the specs of the groups (see [Group]) and the spec returned by [BuildSpec], but not a spec
+// provided with [WithSpec]. Requests and responses are validated against the unprocessed spec. The base spec (see [WithDefaultSpec]) is only copied shallowly, so replace its values instead of changing them.
+func WithSpecPostProcessor(processors ...func(spec *openapi3.T) error) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.specProcessors = append(settings.specProcessors, processors...)
+	}
+}
+
+// WithDereferencedSpec serves the spec with all schema $refs inlined, for clients that cannot follow $refs.
+// Recursive types can not be inlined: their self-references keep their $ref to the components/schemas, which are served as well.
+func WithDereferencedSpec() HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.dereference = true
+	}
+}
+
 // WithEncodings registers additional encodings.
 // Encodings are selected based on the provided "Content-Type" and "Accept" headers
 func WithEncodings(encodings ...Encoding) HandlerOption {
@@ -38,6 +234,14 @@ func WithEncodings(encodings ...Encoding) HandlerOption {
 	}
 }
 
+// WithDefaultEncoding sets the encoding of requests without a "Content-Type" header to the registered encoding of `mimeType`
+// (see [WithEncodings]). Without an "Accept" header, the response is encoded with it as well. Default: application/json
+func WithDefaultEncoding(mimeType string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.defaultEncoding = mimeType
+	}
+}
+
 // WithDefaultSpec allows you to define a base spec.
 // The handler fills this base spec with the operations and schemas
 // reflected from the exposed functions.
@@ -47,17 +251,92 @@ func WithDefaultSpec(spec *openapi3.T) HandlerOption {
 	}
 }
 
-// WithPathPrefix defines the path prefix of the handler.
-// When using it with WithSwaggerUI, make sure that your `Servers` section in
-// the default spec [WithDefaultSpec] adds this prefix as well
+// WithSecurityScheme adds the security scheme `name` to the components of the spec.
+// Use [RequireSecurity] to mark the functions, that require it.
+func WithSecurityScheme(name string, scheme *openapi3.SecurityScheme) HandlerOption {
+	return func(settings *handlerSettings) {
+		if settings.securitySchemes == nil {
+			settings.securitySchemes = openapi3.SecuritySchemes{}
+		}
+		settings.securitySchemes[name] = &openapi3.SecuritySchemeRef{Value: scheme}
+	}
+}
+
+// WithMiddleware wraps the handler with the middlewares `mws`.
+// Every middleware wraps the previous ones, so the last middleware is the outermost and runs first.
+//
+// The middlewares of a request run in this order:
+//  1. the CORS middleware (see [WithCORS])
+//  2. the middlewares of [WithMiddlewareFirst] in the given order
+//  3. the middlewares of [WithMiddleware], the last first
+//  4. the middlewares of [WithMiddlewareLast] in the given order
+//  5. the prefix stripping (see [WithPathPrefix]), right before the request is routed to the function
+func WithMiddleware(mws ...Middleware) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.middlewares = append(settings.middlewares, mws...)
+	}
+}
+
+// WithMiddlewareFirst wraps the handler with the middlewares `mws`, that run before the middlewares of [WithMiddleware],
+// e.g. to log or recover requests, that other middlewares reject. The middlewares run in the given order, also across options.
+func WithMiddlewareFirst(mws ...Middleware) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.firstMiddlewares = append(settings.firstMiddlewares, mws...)
+	}
+}
+
+// WithMiddlewareLast wraps the handler with the middlewares `mws`, that run after the middlewares of [WithMiddleware],
+// but still before the prefix is stripped (see [WithPathPrefix]). The middlewares run in the given order, also across options.
+func WithMiddlewareLast(mws ...Middleware) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.lastMiddlewares = append(settings.lastMiddlewares, mws...)
+	}
+}
+
+// WithInterceptor wraps every call of an exposed function with the `interceptors`, the first interceptor is the outermost.
+// Unlike a [Middleware], an interceptor knows the called function and sees its result and error, before the response is written,
+// e.g. to commit or roll back a transaction of the call. Calls with [Handler.Invoke] are intercepted as well.
+func WithInterceptor(interceptors ...Interceptor) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.interceptors = append(settings.interceptors, interceptors...)
+	}
+}
+
+// WithTagDescription describes the tag `name` in the tags section of the spec.
+// Operations are tagged with the module of their function or the tags set with [WithTags].
+func WithTagDescription(name, description string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.tags = append(settings.tags, &openapi3.Tag{Name: name, Description: description})
+	}
+}
+
+// WithSpecExtension adds the vendor extension `key` (e.g. 'x-api-id') to the root of the spec.
+// The `value` must be JSON serializable. Keys must start with 'x-'. See [WithExtension] for the extensions of operations.
+func WithSpecExtension(key string, value any) HandlerOption {
+	return func(settings *handlerSettings) {
+		if settings.specExtensions == nil {
+			settings.specExtensions = map[string]any{}
+		}
+		settings.specExtensions[key] = value
+	}
+}
+
+// WithCORS allows cross-origin requests as configured by `cfg`.
+// The CORS middleware is the outermost middleware, so that preflight requests are answered before any other middleware runs.
+func WithCORS(cfg CORSConfig) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.cors = &cfg
+	}
+}
+
+// WithPathPrefix defines the path prefix of the handler. The prefix is stripped after all middlewares ran (see [WithMiddleware]),
+// so the middlewares see the full path of the request.
+// The prefix is the url of the server of the spec (see [WithBasePath]), unless the default spec [WithDefaultSpec]
+// defines its own `Servers`, which then must add the prefix as well. Use [WithRequestServerURL] to serve absolute urls.
 func WithPathPrefix(prefixPath string) HandlerOption {
 	return func(settings *handlerSettings) {
 		settings.basePath = prefixPath
-		settings.middlewares = append([]Middleware{
-			func(next http.Handler) http.Handler {
-				return http.StripPrefix(prefixPath, next)
-			},
-		}, settings.middlewares...)
+		settings.reflectSettings.basePath = prefixPath
 	}
 }
 
@@ -80,5 +359,16 @@ func WithSchemaIdentifier(namer SchemaIdentifier) reflectSpecOpt {
 	}
 }
 
+// WithSchemaName sets the schema identifier of the type `t` to `name`, regardless of the [SchemaIdentifier].
+// Use it e.g. to rename a type of a dependency, that clashes with one of your types.
+func WithSchemaName(t reflect.Type, name string) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		if s.schemaNames == nil {
+			s.schemaNames = map[reflect.Type]string{}
+		}
+		s.schemaNames[t] = name
+	}
+}
+
 // TypeNamers are used to generate a schema identifier for a go type
 type SchemaIdentifier func(t reflect.Type) string
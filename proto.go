@@ -0,0 +1,90 @@
+package expose
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/protobuf/proto"
+)
+
+const protoMimeType = "application/x-protobuf"
+
+// ErrNotProtoMessage is returned by the [ProtoEncoding], when a request or result is not a [proto.Message]
+var ErrNotProtoMessage = errors.New("not a proto message")
+
+// ProtoEncoding encodes requests and results, that are [proto.Message]s, as 'application/x-protobuf'. Register it with [WithEncodings]
+// and document the bodies of the functions with [ProtoBody].
+//
+// Functions, whose request is not a proto message, reject protobuf requests with 415 Unsupported Media Type.
+// Results, that are not proto messages, can not be encoded and are answered with 406 Not Acceptable.
+var ProtoEncoding = Encoding{
+	MimeType: protoMimeType,
+	GetDecoder: func(r io.Reader) Decoder {
+		return DecoderFunc(func(v any) error {
+			msg, err := protoMessage(v)
+			if err != nil {
+				return SetErrStatus(err, http.StatusUnsupportedMediaType)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				return io.EOF
+			}
+			return proto.Unmarshal(data, msg)
+		})
+	},
+	GetEncoder: func(w io.Writer) Encoder {
+		return EncoderFunc(func(v any) error {
+			msg, ok := v.(proto.Message)
+			if !ok {
+				return SetErrStatus(fmt.Errorf("%w: %T", ErrNotProtoMessage, v), http.StatusNotAcceptable)
+			}
+			data, err := proto.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		})
+	},
+}
+
+// protoMessage returns the message, that is decoded into `v`. Since the messages are pointers, `v` is usually a pointer to
+// a nil message, which is allocated.
+func protoMessage(v any) (proto.Message, error) {
+	if msg, ok := v.(proto.Message); ok {
+		return msg, nil
+	}
+
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() == reflect.Pointer && ptr.Elem().Kind() == reflect.Pointer {
+		elem := ptr.Elem()
+		if msg, ok := reflect.New(elem.Type().Elem()).Interface().(proto.Message); ok {
+			if elem.IsNil() {
+				elem.Set(reflect.ValueOf(msg))
+			}
+			return elem.Interface().(proto.Message), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+}
+
+// ProtoBody documents the request and the result of the function as binary 'application/x-protobuf' bodies (see [ProtoEncoding]),
+// instead of reflecting their schemas. The requests are not validated, even with [Validate].
+func ProtoBody() FuncOpt {
+	return func(s *functionSettings) {
+		s.protoBody = true
+	}
+}
+
+// protoContent describes a body encoded with the [ProtoEncoding]
+func protoContent() openapi3.Content {
+	return openapi3.NewContentWithSchema(openapi3.NewStringSchema().WithFormat("binary"), []string{protoMimeType})
+}
@@ -0,0 +1,86 @@
+package expose
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoEncoding(t *testing.T) {
+	fns := []Function{
+		Func("/len", func(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.Int64Value, error) {
+			return wrapperspb.Int64(int64(len(req.GetValue()))), nil
+		}, ProtoBody(), Validate(true)),
+		Func("/upper", func(ctx context.Context, req string) (string, error) {
+			return strings.ToUpper(req), nil
+		}),
+	}
+	h, err := NewHandler(fns, WithEncodings(ProtoEncoding))
+	got.T(t).Must().Nil(err)
+
+	t.Run("round trip", func(t *testing.T) {
+		g := got.T(t)
+		body, err := proto.Marshal(wrapperspb.String("hello"))
+		g.Must().Nil(err)
+
+		r := httptest.NewRequest(http.MethodPost, "/len", bytes.NewReader(body))
+		r.Header.Set("content-type", "application/x-protobuf")
+		r.Header.Set("accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("content-type"), "application/x-protobuf")
+
+		var res wrapperspb.Int64Value
+		g.Must().Nil(proto.Unmarshal(w.Body.Bytes(), &res))
+		g.Eq(res.GetValue(), int64(5))
+	})
+
+	t.Run("non proto request", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/upper", strings.NewReader("abc"))
+		r.Header.Set("content-type", "application/x-protobuf")
+		r.Header.Set("accept", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusUnsupportedMediaType)
+		g.Has(w.Body.String(), ErrNotProtoMessage.Error())
+	})
+
+	t.Run("non proto result", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/upper", strings.NewReader(`"abc"`))
+		r.Header.Set("content-type", "application/json")
+		r.Header.Set("accept", "application/x-protobuf")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusNotAcceptable)
+		g.Has(w.Body.String(), ErrNotProtoMessage.Error())
+	})
+
+	t.Run("spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+
+		op := spec.Paths.Find("/len").Post
+		req := op.RequestBody.Value.Content.Get("application/x-protobuf")
+		g.Must().NotNil(req)
+		g.Eq(req.Schema.Value.Format, "binary")
+		g.Nil(op.RequestBody.Value.Content.Get("application/json"))
+
+		res := op.Responses.Status(200).Value.Content.Get("application/x-protobuf")
+		g.Must().NotNil(res)
+		g.Eq(res.Schema.Value.Format, "binary")
+	})
+}
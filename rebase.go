@@ -0,0 +1,41 @@
+package expose
+
+import "strings"
+
+// Rebase mounts `fn` under `prefix`, e.g. '/counter/inc' becomes '/v1/counter/inc' with the prefix '/v1'.
+// The [Function.Module] is derived from the new path and the [Aliases] are mounted under `prefix` as well.
+func Rebase(fn Function, prefix string) Function {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return fn
+	}
+	prefix = "/" + prefix
+
+	if r, ok := fn.(rebaser); ok {
+		return r.rebase(prefix)
+	}
+	return &rebasedFunction{Function: fn, path: prefix + fn.Path()}
+}
+
+// rebaser is implemented by the functions created with [Func] and its variants, that can change their path themselves.
+type rebaser interface {
+	rebase(prefix string) Function
+}
+
+// rebasedFunction mounts a custom [Function] at another path
+type rebasedFunction struct {
+	Function
+	path string
+}
+
+func (fn *rebasedFunction) Module() string {
+	return moduleOf(fn.path)
+}
+
+func (fn *rebasedFunction) Path() string {
+	return fn.path
+}
+
+func (fn *rebasedFunction) funcSettings() functionSettings {
+	return getFuncSettings(fn.Function)
+}
@@ -0,0 +1,40 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestRebase(t *testing.T) {
+	g := got.T(t)
+
+	fn := Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+		return delta, nil
+	}, Aliases("/old/inc"), Validate(true))
+
+	rebased := Rebase(fn, "/v1/")
+
+	g.Eq(rebased.Path(), "/v1/counter/inc")
+	g.Eq(rebased.Module(), "v1.counter")
+	g.Eq(rebased.Name(), "inc")
+	g.Eq(getFuncSettings(rebased).aliases, []string{"/v1/old/inc"})
+	g.Eq(getFuncSettings(rebased).validate, true)
+
+	g.Eq(fn.Path(), "/counter/inc")
+	g.Eq(Rebase(fn, "/"), fn)
+
+	h, err := NewHandler([]Function{rebased})
+	g.Must().Nil(err)
+
+	for _, path := range []string{"/v1/counter/inc", "/v1/old/inc"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader("2")))
+		g.Desc(path).Eq(w.Code, http.StatusOK)
+		g.Desc(path).Eq(strings.TrimSpace(w.Body.String()), "2")
+	}
+}
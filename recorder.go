@@ -0,0 +1,57 @@
+package expose
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseRecorder records a response, e.g. to cache it (see [Cache]) or to replay it (see [WithIdempotency]).
+// When it wraps a [http.ResponseWriter], the response is written through, otherwise it is buffered until it is written with writeTo.
+type responseRecorder struct {
+	w           http.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// newResponseRecorder records the response, that is written through to `w`, or buffers it, when `w` is nil
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{w: w, header: http.Header{}, status: http.StatusOK}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+	if rec.w != nil {
+		for name, values := range rec.header {
+			rec.w.Header()[name] = values
+		}
+		rec.w.WriteHeader(status)
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	rec.body.Write(b)
+	if rec.w != nil {
+		return rec.w.Write(b)
+	}
+	return len(b), nil
+}
+
+// writeTo writes the buffered response to `w`
+func (rec *responseRecorder) writeTo(w http.ResponseWriter) {
+	for name, values := range rec.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(rec.status)
+	w.Write(rec.body.Bytes())
+}
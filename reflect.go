@@ -1,8 +1,12 @@
 package expose
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -10,9 +14,32 @@ import (
 )
 
 type reflectSettings struct {
-	mapper                SchemaMapper
-	typeNamer             SchemaIdentifier
+	mapper    SchemaMapper
+	typeNamer SchemaIdentifier
+	// schemaNames are the identifiers registered with [WithSchemaName], that take precedence over the typeNamer
+	schemaNames map[reflect.Type]string
+	operationID func(fn Function) string
+	// module derives the module of a function from its path, see [WithModuleFunc]
+	module                func(path string) string
 	skipExtractSubSchemas bool
+	enums                 map[reflect.Type]enumSchema
+	// errorResponses are the error statuses with their error codes, that are documented for every operation
+	errorResponses map[int][]string
+	// errorSchema is reflected as schema of the error responses
+	errorSchema any
+	// envelope wraps the results, see [WithResponseEnvelope]
+	envelope func(res any) any
+	cache    *SchemaCache
+	// webhooks are documented in the spec, see [WithWebhook]
+	webhooks []webhook
+	// openAPIVersion is the version of the reflected spec, see [WithOpenAPIVersion]
+	openAPIVersion string
+	// fieldNames renames the properties of struct fields without a `json` name tag, see [WithFieldNames]
+	fieldNames *fieldNamer
+	// basePath is the url of the default server, see [WithBasePath]
+	basePath string
+	// schemaVariants separates the schemas of requests and responses, see [SeparateRequestResponseSchemas]
+	schemaVariants bool
 }
 
 type reflectSpecOpt func(s *reflectSettings)
@@ -29,20 +56,15 @@ func withSettings(settings reflectSettings) reflectSpecOpt {
 	}
 }
 
-// ReflectSpec reflects all provided exposed functions `fns` and generates
-// an openapi3 specification.
-// The provided spec is the template for the resulting specification. Use it e.g. to define
-// the spec info or additional schemas and operations
-func ReflectSpec(root openapi3.T, fns []Function, opts ...reflectSpecOpt) (openapi3.T, error) {
-	fail := func(err error) (openapi3.T, error) {
-		return openapi3.T{}, fmt.Errorf("failed to reflect openapi spec: %w", err)
-	}
-
+// newReflectSettings applies the `opts` to the default reflection settings
+func newReflectSettings(opts ...reflectSpecOpt) reflectSettings {
 	settings := reflectSettings{
 		mapper: func(t reflect.Type) *openapi3.Schema {
 			return nil
 		},
-		typeNamer: DefaultSchemaIdentifier,
+		typeNamer:      DefaultSchemaIdentifier,
+		operationID:    DefaultOperationID,
+		openAPIVersion: "3.0.2",
 	}
 
 	for _, opt := range opts {
@@ -51,22 +73,69 @@ func ReflectSpec(root openapi3.T, fns []Function, opts ...reflectSpecOpt) (opena
 		}
 		opt(&settings)
 	}
+	return settings
+}
+
+// ReflectSpec reflects all provided exposed functions `fns` and generates
+// an openapi3 specification.
+// The provided spec is the template for the resulting specification. Use it e.g. to define
+// the spec info or additional schemas and operations
+func ReflectSpec(root openapi3.T, fns []Function, opts ...reflectSpecOpt) (openapi3.T, error) {
+	fail := func(err error) (openapi3.T, error) {
+		return openapi3.T{}, fmt.Errorf("failed to reflect openapi spec: %w", err)
+	}
+
+	settings := newReflectSettings(opts...)
+	fns = withoutCatchAlls(fns)
 
-	root.OpenAPI = "3.0.2"
+	if !strings.HasPrefix(settings.openAPIVersion, "3.0.") && !strings.HasPrefix(settings.openAPIVersion, "3.1.") {
+		return fail(fmt.Errorf("unsupported openapi version '%s'", settings.openAPIVersion))
+	}
+	root.OpenAPI = settings.openAPIVersion
+	if settings.basePath != "" && len(root.Servers) == 0 {
+		root.Servers = openapi3.Servers{{URL: strings.TrimSuffix(settings.basePath, "/")}}
+	}
 
+	// copy the components, so that the provided spec is not mutated
 	components := openapi3.NewComponents()
-	if root.Components == nil {
-		root.Components = &components
+	if root.Components != nil {
+		components = *root.Components
 	}
-	if components.Schemas == nil {
-		components.Schemas = openapi3.Schemas{}
+	schemas := openapi3.Schemas{}
+	for id, s := range components.Schemas {
+		schemas[id] = s
+	}
+	components.Schemas = schemas
+	root.Components = &components
+
+	var errSchema *openapi3.SchemaRef
+	if settings.errorSchema != nil {
+		var err error
+		if errSchema, err = reflectSchema(settings.errorSchema, components.Schemas, settings); err != nil {
+			return fail(err)
+		}
+	}
+	newErrorResponse := func(description string) *openapi3.ResponseRef {
+		response := openapi3.NewResponse().WithDescription(description)
+		if errSchema != nil {
+			response.WithJSONSchemaRef(errSchema)
+		}
+		return &openapi3.ResponseRef{Value: response}
 	}
 
 	for _, fn := range fns {
+		fn := settings.withModule(fn)
 		op := openapi3.NewOperation()
-		op.OperationID = fmt.Sprint(fn.Module(), "#", fn.Name())
+		op.OperationID = settings.operationID(fn)
 
-		if _, ok := fn.Req().(Void); !ok {
+		fnSettings := getFuncSettings(fn)
+
+		switch {
+		case isVoid(fn.Req()):
+			// nullary functions have no request body
+		case fnSettings.protoBody:
+			op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithContent(protoContent())}
+		default:
 			body := openapi3.NewRequestBody()
 			reqSchemaRef, err := reflectSchema(fn.Req(), components.Schemas, settings)
 			if err != nil {
@@ -75,30 +144,132 @@ func ReflectSpec(root openapi3.T, fns []Function, opts ...reflectSpecOpt) (opena
 
 			body.WithSchemaRef(
 				reqSchemaRef,
-				[]string{"application/json"})
+				[]string{requestMediaType(fn.Req())})
 
+			// the body is not required, since an empty body is decoded as the zero value of the request
 			op.RequestBody = &openapi3.RequestBodyRef{}
 			op.RequestBody.Value = body
 		}
 
 		response := openapi3.NewResponse()
 
-		resSchema, err := reflectSchema(fn.Res(), components.Schemas, settings)
-		if err != nil {
+		void := isVoid(fn.Res())
+		if _, blob := fn.Res().(Blob); blob {
+			response.Content = blobContent(fn)
+		} else if fnSettings.protoBody && !void {
+			response.Content = protoContent()
+		} else {
+			res := fn.Res()
+			if void {
+				res = Void{}
+			}
+			resSchema, err := reflectSchema(res, components.Schemas, settings)
+			if err != nil {
+				return fail(err)
+			}
+			if !void && settings.envelope != nil {
+				if resSchema, err = reflectEnvelope(settings.envelope(fn.Res()), fn.Res(), resSchema, components.Schemas, settings); err != nil {
+					return fail(err)
+				}
+			}
+
+			response.WithJSONSchemaRef(resSchema)
+		}
+		for _, h := range getFuncSettings(fn).headers {
+			if response.Headers == nil {
+				response.Headers = openapi3.Headers{}
+			}
+			header := &openapi3.Header{Parameter: openapi3.Parameter{Description: h.description}}
+			if h.schema != nil {
+				header.Schema = openapi3.NewSchemaRef("", h.schema)
+			}
+			response.Headers[h.name] = &openapi3.HeaderRef{Value: header}
+		}
+		op.AddResponse(200, response)
+
+		if errSchema != nil {
+			op.Responses.Set("4XX", newErrorResponse("Client Error"))
+			op.Responses.Set("5XX", newErrorResponse("Server Error"))
+		}
+
+		for status, codes := range settings.errorResponses {
+			codes := slices.Clone(codes)
+			slices.Sort(codes)
+			op.Responses.Set(strconv.Itoa(status), newErrorResponse(strings.Join(codes, ", ")))
+		}
+
+		for _, r := range getFuncSettings(fn).errorResponses {
+			op.Responses.Set(strconv.Itoa(r.status), newErrorResponse(r.description))
+		}
+
+		if tags := getFuncSettings(fn).tags; len(tags) > 0 {
+			op.Tags = append(op.Tags, tags...)
+		} else {
+			op.Tags = append(op.Tags, fn.Module())
+		}
+
+		if getFuncSettings(fn).idempotent {
+			key := openapi3.NewHeaderParameter(IdempotencyKeyHeader).
+				WithDescription("Repeated requests with the same key receive the response of the first request").
+				WithSchema(openapi3.NewStringSchema())
+			op.AddParameter(key)
+		}
+
+		if getFuncSettings(fn).cacheTTL > 0 && !void {
+			ifNoneMatch := openapi3.NewHeaderParameter("If-None-Match").
+				WithDescription("The ETag of a cached response, that is answered with 304 Not Modified, when it is still current").
+				WithSchema(openapi3.NewStringSchema())
+			op.AddParameter(ifNoneMatch)
+			ok := op.Responses.Value("200").Value
+			if ok.Headers == nil {
+				ok.Headers = openapi3.Headers{}
+			}
+			ok.Headers["ETag"] = &openapi3.HeaderRef{Value: &openapi3.Header{
+				Parameter: openapi3.Parameter{Description: "The entity tag of the response", Schema: openapi3.NewStringSchema().NewRef()},
+			}}
+			op.AddResponse(http.StatusNotModified, openapi3.NewResponse().WithDescription("Not Modified"))
+		}
+
+		if security := getFuncSettings(fn).security; len(security) > 0 {
+			op.Security = &security
+		}
+
+		for key, value := range getFuncSettings(fn).extensions {
+			if err := checkExtensionKey(key); err != nil {
+				return fail(fmt.Errorf("%s: %w", fn.Path(), err))
+			}
+			if op.Extensions == nil {
+				op.Extensions = map[string]any{}
+			}
+			op.Extensions[key] = value
+		}
+
+		if err := settings.addExamples(fn, op); err != nil {
 			return fail(err)
 		}
 
-		response.WithJSONSchemaRef(resSchema)
-		op.AddResponse(200, response)
+		addOperations(&root, fn, op, components.Schemas)
+	}
 
-		op.Tags = append(op.Tags, fn.Module())
+	if settings.schemaVariants {
+		separateSchemaVariants(&root, components.Schemas)
+	}
 
-		root.AddOperation(fn.Path(), "POST", op)
+	if err := settings.reflectWebhooks(&root, components.Schemas); err != nil {
+		return fail(err)
 	}
 
 	return root, nil
 }
 
+// checkExtensionKey fails, when `key` is not a valid key of a vendor extension
+func checkExtensionKey(key string) error {
+	if !strings.HasPrefix(key, "x-") {
+		return fmt.Errorf("the extension '%s' must start with 'x-'", key)
+	}
+	return nil
+}
+
 type SchemaMapper func(t reflect.Type) *openapi3.Schema
 
 // reflectSchema reflects the type of `val` and returns a `openapi3.SchemaRef`
@@ -123,36 +294,116 @@ func reflectSchema(val any, schemas openapi3.Schemas, settings reflectSettings)
 
 	t := reflect.TypeOf(val)
 
-	id := settings.typeNamer(t)
+	if isStructList(t) && settings.mapper(t) == nil {
+		// the list itself is described inline, only the element is moved to the components/schemas
+		items, err := reflectSchema(reflect.Zero(t.Elem()).Interface(), schemas, settings)
+		if err != nil {
+			return fail(err)
+		}
+		list := openapi3.NewArraySchema()
+		// the resolved element allows to validate values against the list schema
+		list.Items = openapi3.NewSchemaRef(items.Ref, schemas[strings.TrimPrefix(items.Ref, "#/components/schemas/")].Value)
+		return openapi3.NewSchemaRef("", list), nil
+	}
+
+	if t.Kind() == reflect.Map && t.Name() == "" && settings.mapper(t) == nil {
+		// the map itself is described inline, only its values are moved to the components/schemas
+		ref, err := generateSchema(val, schemas, settings)
+		if err != nil {
+			return fail(err)
+		}
+		return ref, nil
+	}
+
+	id := settings.schemaID(t)
 	if _, ok := schemas[id]; ok {
 		return openapi3.NewSchemaRef("#/components/schemas/"+id, nil), nil
 	}
 
+	ref, err := generateSchema(val, schemas, settings)
+	if err != nil {
+		return fail(err)
+	}
+	schemas[id] = ref
+
+	return openapi3.NewSchemaRef("#/components/schemas/"+id, nil), nil
+
+}
+
+// schemaID identifies the schema of `t` with the name registered by [WithSchemaName] or the configured [SchemaIdentifier]
+func (settings reflectSettings) schemaID(t reflect.Type) string {
+	named := t
+	for named.Kind() == reflect.Pointer {
+		named = named.Elem()
+	}
+	if name, ok := settings.schemaNames[named]; ok {
+		return name
+	}
+	return settings.typeNamer(t)
+}
+
+// generateSchema generates the schema of `val` and moves its sub schemas to `schemas`, unless [SkipExtractSubSchemas] is set.
+// The schema is taken from the [SchemaCache], when there is one.
+func generateSchema(val any, schemas openapi3.Schemas, settings reflectSettings) (*openapi3.SchemaRef, error) {
+	if settings.cache == nil {
+		return generateSchemaUncached(val, schemas, settings)
+	}
+	return settings.cache.generate(val, settings.skipExtractSubSchemas, schemas, func(schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+		return generateSchemaUncached(val, schemas, settings)
+	})
+}
+
+func generateSchemaUncached(val any, schemas openapi3.Schemas, settings reflectSettings) (*openapi3.SchemaRef, error) {
+	t := reflect.TypeOf(val)
+
 	var gen openapi3gen.Generator
 
 	gen = *openapi3gen.NewGenerator(
 		openapi3gen.UseAllExportedFields(),
+		// recursive types are replaced with a $ref to their schema
+		openapi3gen.CreateTypeNameGenerator(settings.schemaID),
 		openapi3gen.SchemaCustomizer(
 			newCustomizerFlow(
-				setID(t, settings.typeNamer),
+				requireStringKeys(),
+				setID(t, settings.schemaID),
 				tryMap(settings.mapper),
 				useCutomType(&gen, schemas),
+				useInt64Strings(settings.fieldNames),
+				useEnum(settings.enums),
+				useFormat(),
+				useConstraints(),
+				useDefault(),
+				markPointersNullable(settings.openAPI31()),
+				markAccessModes(),
 				markPropertiesRequired(),
+				renameProperties(settings.fieldNames),
 			)))
-	ref, err := gen.NewSchemaRefForValue(val, schemas)
+	// the schemas of recursive types, that the generator collects, are not used: they are not always complete,
+	// since the generator is still working on them. The extraction collects them instead.
+	ref, err := gen.NewSchemaRefForValue(val, openapi3.Schemas{})
 	if err != nil {
-		return fail(err)
+		return nil, err
 	}
-	schemas[id] = ref
 
 	if !settings.skipExtractSubSchemas && ref.Value != nil {
 		if err := walkSchema(ref, extractSubSchemas(schemas)); err != nil {
-			return fail(err)
+			return nil, err
 		}
 	}
 
-	return openapi3.NewSchemaRef("#/components/schemas/"+id, nil), nil
+	return ref, nil
+}
 
+// isStructList reports whether `t` is an unnamed slice of structs or struct pointers, like `[]User`
+func isStructList(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice || t.Name() != "" {
+		return false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
 }
 
 // extractSubSchemas creates a visitor, that moves the schema in `ref` to the provided `schemas`
@@ -183,6 +434,155 @@ func extractSubSchemas(schemas openapi3.Schemas) visitorFn {
 	}
 }
 
+// inlineRefs creates a visitor, that replaces resolved $refs with the referenced schema.
+// It is the inverse of [extractSubSchemas].
+func inlineRefs() visitorFn {
+	return func(ref *openapi3.SchemaRef) (*openapi3.SchemaRef, error) {
+		if ref.Ref == "" {
+			return nil, nil
+		}
+		return openapi3.NewSchemaRef("", ref.Value), nil
+	}
+}
+
+// dereferenceSpec returns a copy of `spec`, in which all schema $refs are replaced with the referenced schemas,
+// except for the self-references of recursive types, which can not be inlined.
+func dereferenceSpec(spec openapi3.T) (openapi3.T, error) {
+	fail := func(err error) (openapi3.T, error) {
+		return openapi3.T{}, fmt.Errorf("failed to dereference spec: %w", err)
+	}
+
+	// the roundtrip creates a deep copy, with all refs resolved
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fail(err)
+	}
+	resolved, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return fail(err)
+	}
+
+	inline := func(content openapi3.Content) error {
+		for _, mediaType := range content {
+			if mediaType.Schema == nil {
+				continue
+			}
+			if err := walkSchema(mediaType.Schema, inlineRefs()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for path, item := range resolved.Paths.Map() {
+		for method, op := range item.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				if err := inline(op.RequestBody.Value.Content); err != nil {
+					return fail(fmt.Errorf("%s %s: %w", method, path, err))
+				}
+			}
+			for status, res := range op.Responses.Map() {
+				if res.Value == nil {
+					continue
+				}
+				if err := inline(res.Value.Content); err != nil {
+					return fail(fmt.Errorf("%s %s %s: %w", method, path, status, err))
+				}
+			}
+		}
+	}
+
+	if resolved.Components != nil {
+		for id, ref := range resolved.Components.Schemas {
+			if err := walkSchema(ref, inlineRefs()); err != nil {
+				return fail(fmt.Errorf("schema %s: %w", id, err))
+			}
+		}
+	}
+
+	return *resolved, nil
+}
+
+// WithOperationID overrides how the operationId of a function is derived. Default: [DefaultOperationID]
+func WithOperationID(operationID func(fn Function) string) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.operationID = operationID
+	}
+}
+
+// WithOpenAPIVersion sets the openapi version of the reflected spec. The versions 3.0.x and 3.1.x are supported. Default: 3.0.2
+//
+// With 3.1, nullable properties permit the `null` type (e.g. `type: [string, "null"]`) instead of setting `nullable: true`,
+// and webhooks (see [WithWebhook]) are listed in the `webhooks` section instead of the `x-webhooks` extension.
+// The spec is still modelled with kin-openapi, which understands 3.0: the request and response validation supports the `null` type,
+// but [openapi3.T.Validate] rejects it, and 3.1 only features, like `const` or `$defs` in schemas, are not reflected.
+func WithOpenAPIVersion(version string) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.openAPIVersion = version
+	}
+}
+
+// WithBasePath documents, that the functions are exposed under the path `basePath`, e.g. '/rpc' for the function '/counter/inc'
+// at '/rpc/counter/inc'. The operation paths remain the paths of the functions, the base path is the relative url of the server
+// of the spec instead, so clients resolve the operations against the host of the spec.
+// The servers of the root spec (see [WithDefaultSpec]) take precedence, the base path is only used, when it defines none.
+//
+// [WithPathPrefix] sets the base path of the handler.
+func WithBasePath(basePath string) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.basePath = basePath
+	}
+}
+
+// ReflectErrorSchema documents the shape of the error responses. The type of `v` is reflected into the
+// components/schemas and used as `4XX` and `5XX` response of every operation. [WithErrorSchema] sets it for a handler.
+func ReflectErrorSchema(v any) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.errorSchema = v
+	}
+}
+
+// openAPI31 reports whether the reflected spec is an openapi 3.1 spec
+func (settings reflectSettings) openAPI31() bool {
+	return strings.HasPrefix(settings.openAPIVersion, "3.1.")
+}
+
+// WithModuleFunc overrides how the module of a function is derived from its path, e.g. to group the operations
+// only by the first path segment. The module is the default tag and part of the operationId (see [DefaultOperationID]).
+// Default: the path without the last segment, with dots as separators, e.g. 'app.commands' for '/app/commands/inc'
+func WithModuleFunc(module func(path string) string) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.module = module
+	}
+}
+
+// moduleFunction overrides the module of a [Function], see [WithModuleFunc]
+type moduleFunction struct {
+	Function
+	module string
+}
+
+func (fn *moduleFunction) Module() string {
+	return fn.module
+}
+
+func (fn *moduleFunction) funcSettings() functionSettings {
+	return getFuncSettings(fn.Function)
+}
+
+// withModule returns `fn` with the module derived by the [WithModuleFunc]
+func (settings reflectSettings) withModule(fn Function) Function {
+	if settings.module == nil {
+		return fn
+	}
+	return &moduleFunction{Function: fn, module: settings.module(fn.Path())}
+}
+
+// DefaultOperationID creates the operationId of a function in the form of '<module>#<name>'
+func DefaultOperationID(fn Function) string {
+	return fmt.Sprint(fn.Module(), "#", fn.Name())
+}
+
 // DefaultSchemaIdentifier creates a schema identifier for the provided type `t`
 // in the form of '<path>.<to>.<my>.<package>.<name>
 func DefaultSchemaIdentifier(t reflect.Type) string {
@@ -224,6 +624,7 @@ func ShortSchemaIdentifier(t reflect.Type) string {
 // It returns all fields, that are not flagged with `omitempty`
 // Fields without a `json` struct tag are returned as is.
 // Fields with the `json` return their alias instead
+// Pointer fields are optional, unless they are tagged with `required:"true"`
 func getRequiredProps(t reflect.Type) []string {
 
 	if t.Kind() == reflect.Pointer {
@@ -241,35 +642,57 @@ func getRequiredProps(t reflect.Type) []string {
 			continue
 		}
 
-		jsonTag := f.Tag.Get("json")
-		if jsonTag == "" {
-			props = append(props, f.Name)
+		name, omitempty := jsonName(f)
+		if omitempty || name == "-" {
 			continue
 		}
 
-		alias, option, found := strings.Cut(jsonTag, ",")
-
-		if option == "omitempty" {
+		if f.Type.Kind() == reflect.Pointer && f.Tag.Get("required") != "true" {
 			continue
 		}
 
-		name := jsonTag
-		if found {
-			name = alias
-		}
-		if name == "" {
-			name = f.Name
-		}
+		props = append(props, name)
+	}
+	return props
+}
 
-		if name == "-" {
+// getPointerProps returns the names of all pointer fields of the struct `t`, like [getRequiredProps]
+func getPointerProps(t reflect.Type) []string {
+	if t.Kind() == reflect.Pointer {
+		return getPointerProps(t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var props []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			props = append(props, getPointerProps(f.Type)...)
 			continue
 		}
 
-		props = append(props, name)
+		if name, _ := jsonName(f); name != "-" && f.Type.Kind() == reflect.Pointer {
+			props = append(props, name)
+		}
 	}
 	return props
 }
 
+// jsonName returns the name of the field `f` in its json representation and whether it is flagged with `omitempty`.
+// Ignored fields are named "-".
+func jsonName(f reflect.StructField) (name string, omitempty bool) {
+	alias, option, _ := strings.Cut(f.Tag.Get("json"), ",")
+
+	name = alias
+	if name == "" {
+		name = f.Name
+	}
+
+	return name, option == "omitempty"
+}
+
 // SchemaProvider overrides the schema reflection with the provided custom type
 type SchemaProvider interface {
 	JSONSchema(gen *openapi3gen.Generator, schemas openapi3.Schemas) (*openapi3.SchemaRef, error)
@@ -295,6 +718,16 @@ func setID(mainType reflect.Type, namer SchemaIdentifier) customizerPipe {
 	}
 }
 
+// requireStringKeys rejects maps with keys other than strings, since json objects only have string keys
+func requireStringKeys() customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		if t.Kind() == reflect.Map && t.Key().Kind() != reflect.String {
+			return true, fmt.Errorf("unsupported map key type %s of %s: only string keys are supported", t.Key(), t)
+		}
+		return
+	}
+}
+
 // tryMap uses the user defined mappings to acquire the schema of a type. When a schema is found, no further customizations will be applied.
 func tryMap(mapper SchemaMapper) customizerPipe {
 	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
@@ -327,6 +760,372 @@ func useCutomType(gen *openapi3gen.Generator, schemas openapi3.Schemas) customiz
 	}
 }
 
+// enumSchema holds the values and their names of an enum registered with [RegisterIntEnum]
+type enumSchema struct {
+	values   []any
+	varnames []string
+}
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// RegisterIntEnum documents the values of the int-backed enum type `T` and their `names`.
+// The schema of `T` lists the values in `enum` and their names in the `x-enum-varnames` extension,
+// which is understood by many client generators.
+func RegisterIntEnum[T integer](names map[T]string) reflectSpecOpt {
+	values := make([]T, 0, len(names))
+	for v := range names {
+		values = append(values, v)
+	}
+	slices.Sort(values)
+
+	enum := enumSchema{}
+	for _, v := range values {
+		enum.values = append(enum.values, float64(v))
+		enum.varnames = append(enum.varnames, names[v])
+	}
+
+	t := reflect.TypeOf(*new(T))
+
+	return func(s *reflectSettings) {
+		if s.enums == nil {
+			s.enums = map[reflect.Type]enumSchema{}
+		}
+		s.enums[t] = enum
+	}
+}
+
+// useInt64Strings describes int64 and uint64 values as strings with the format 'int64' or 'uint64', when the `namer` encodes them
+// as strings, see [WithInt64AsString]
+func useInt64Strings(namer *fieldNamer) customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if namer == nil || !namer.int64Strings || customJSON(t) || (t.Kind() != reflect.Int64 && t.Kind() != reflect.Uint64) {
+			return
+		}
+		schema.Type = &openapi3.Types{openapi3.TypeString}
+		schema.Format = "int64"
+		if t.Kind() == reflect.Uint64 {
+			schema.Format = "uint64"
+		}
+		schema.Min, schema.Max = nil, nil
+		return
+	}
+}
+
+// useEnum sets the values and names of the enums registered with [RegisterIntEnum]
+func useEnum(enums map[reflect.Type]enumSchema) customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		enum, ok := enums[t]
+		if !ok {
+			return
+		}
+		schema.Enum = enum.values
+		if schema.Extensions == nil {
+			schema.Extensions = make(map[string]interface{})
+		}
+		schema.Extensions["x-enum-varnames"] = enum.varnames
+		return
+	}
+}
+
+// useFormat sets the format of a schema to the value of the `format` struct tag, e.g. `format:"email"`.
+//
+// With [Validate], the formats 'byte', 'date', 'date-time', 'ipv4' and 'ipv6' are enforced.
+// Further formats can be registered with [openapi3.DefineStringFormat], e.g. 'email' with [openapi3.FormatOfStringForEmail].
+// Other formats are only documented.
+func useFormat() customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		if format := tag.Get("format"); format != "" {
+			schema.Format = format
+		}
+		return
+	}
+}
+
+// useDefault sets the default value declared in the `default` struct tag, e.g. `default:"10"`.
+// The value is converted to the type of the field. Lists and maps are declared as JSON, e.g. `default:"[\"a\",\"b\"]"`.
+// Fields with a default are still required, unless they are `omitempty`.
+// The default is only documented, use [RequestDefaults] or a [Defaulter] to apply it to the requests.
+func useDefault() customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		value, ok := tag.Lookup("default")
+		if !ok {
+			return
+		}
+
+		fail := func(err error) (bool, error) {
+			return true, fmt.Errorf("invalid default `%s` of %s: %w", value, name, err)
+		}
+
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.String:
+			schema.Default = value
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fail(err)
+			}
+			schema.Default = b
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(value, 10, t.Bits())
+			if err != nil {
+				return fail(err)
+			}
+			schema.Default = n
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(value, 10, t.Bits())
+			if err != nil {
+				return fail(err)
+			}
+			schema.Default = n
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(value, t.Bits())
+			if err != nil {
+				return fail(err)
+			}
+			schema.Default = n
+		case reflect.Slice, reflect.Array, reflect.Map:
+			v := reflect.New(t)
+			if err := json.Unmarshal([]byte(value), v.Interface()); err != nil {
+				return fail(err)
+			}
+			schema.Default = v.Elem().Interface()
+		default:
+			return fail(fmt.Errorf("defaults of %s are not supported", t))
+		}
+		return
+	}
+}
+
+// useConstraints sets the constraints declared in the `validate` struct tag, e.g. `validate:"min=1,max=100"`.
+// Supported are `min`, `max`, `minLength`, `maxLength`, `pattern` and `enum`, whose values are separated by `|`, e.g. `enum=red|green`.
+// Like in the validator convention, `min` and `max` limit the length of strings, the items of arrays and the properties of maps,
+// and the value of numbers. Since the pattern may contain commas, it has to be the last constraint. Other constraints are ignored.
+func useConstraints() customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		constraints := tag.Get("validate")
+		if constraints == "" {
+			return
+		}
+
+		fail := func(err error) (bool, error) {
+			return true, fmt.Errorf("invalid constraints `%s` of %s: %w", constraints, name, err)
+		}
+
+		for rest := constraints; rest != ""; {
+			var constraint string
+			if rest = strings.TrimSpace(rest); strings.HasPrefix(rest, "pattern=") {
+				constraint, rest = rest, ""
+			} else {
+				constraint, rest, _ = strings.Cut(rest, ",")
+			}
+
+			key, value, _ := strings.Cut(strings.TrimSpace(constraint), "=")
+			switch key {
+			case "min", "max":
+				if err := setBound(schema, key == "min", value); err != nil {
+					return fail(err)
+				}
+			case "minLength", "maxLength":
+				n, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					return fail(err)
+				}
+				if key == "minLength" {
+					schema.MinLength = n
+				} else {
+					schema.MaxLength = &n
+				}
+			case "pattern":
+				schema.Pattern = value
+			case "enum":
+				schema.Enum = nil
+				for _, v := range strings.Split(value, "|") {
+					enumValue, err := parseEnumValue(t, v)
+					if err != nil {
+						return fail(err)
+					}
+					schema.Enum = append(schema.Enum, enumValue)
+				}
+			}
+		}
+		return
+	}
+}
+
+// setBound sets the lower or upper bound `value` of the `min` and `max` constraints: the length of strings,
+// the number of items of arrays, the number of properties of objects and the value of numbers
+func setBound(schema *openapi3.Schema, lower bool, value string) error {
+	switch {
+	case schema.Type.Includes(openapi3.TypeString), schema.Type.Includes(openapi3.TypeArray), schema.Type.Includes(openapi3.TypeObject):
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		switch {
+		case schema.Type.Includes(openapi3.TypeString) && lower:
+			schema.MinLength = n
+		case schema.Type.Includes(openapi3.TypeString):
+			schema.MaxLength = &n
+		case schema.Type.Includes(openapi3.TypeArray) && lower:
+			schema.MinItems = n
+		case schema.Type.Includes(openapi3.TypeArray):
+			schema.MaxItems = &n
+		case lower:
+			schema.MinProps = n
+		default:
+			schema.MaxProps = &n
+		}
+	default:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if lower {
+			schema.Min = &n
+		} else {
+			schema.Max = &n
+		}
+	}
+	return nil
+}
+
+// parseEnumValue parses the enum value `v` according to the kind of `t`, which is dereferenced.
+// Numbers are float64s, like the numbers in decoded JSON.
+func parseEnumValue(t reflect.Type, v string) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(v, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+
+// markPointersNullable flags the properties of pointer fields as nullable.
+// Struct schemas are moved to the components/schemas and replaced with a $ref, which can not be nullable.
+// So they are wrapped in a nullable `allOf`, just like the $refs of recursive types.
+//
+// With `nullType` (openapi 3.1), the properties permit the `null` type instead, and $refs are wrapped in an `anyOf` with the `null` type.
+func markPointersNullable(nullType bool) customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		for _, prop := range getPointerProps(t) {
+			ref, ok := schema.Properties[prop]
+			if !ok || ref.Value == nil {
+				continue
+			}
+			_, extracted := ref.Value.Extensions["$id"]
+			extracted = extracted || strings.HasPrefix(ref.Ref, "#/components/schemas/")
+			if nullType {
+				if !extracted && len(ref.Value.Type.Slice()) > 0 {
+					if !ref.Value.Type.Includes(openapi3.TypeNull) {
+						types := append(openapi3.Types{}, ref.Value.Type.Slice()...)
+						types = append(types, openapi3.TypeNull)
+						ref.Value.Type = &types
+					}
+					continue
+				}
+				nullable := openapi3.NewSchema()
+				nullable.AnyOf = openapi3.SchemaRefs{ref, openapi3.NewSchemaRef("", &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeNull}})}
+				schema.Properties[prop] = openapi3.NewSchemaRef("", nullable)
+				continue
+			}
+			if extracted {
+				nullable := openapi3.NewSchema()
+				nullable.Nullable = true
+				nullable.AllOf = openapi3.SchemaRefs{ref}
+				schema.Properties[prop] = openapi3.NewSchemaRef("", nullable)
+				continue
+			}
+			ref.Value.Nullable = true
+		}
+		return
+	}
+}
+
+// markAccessModes flags the properties of fields, that are tagged with `openapi:"readOnly"` or `openapi:"writeOnly"`,
+// as readOnly or writeOnly, e.g. server generated ids or passwords. The flags are only documented, requests and responses are not
+// checked against them.
+//
+// Types, that are used in requests and responses, share one schema in the components/schemas, so the read-only fields remain required.
+// As defined by openapi, a required readOnly property is only required in responses and a required writeOnly property only
+// in requests, which generators of clients usually respect. [SeparateRequestResponseSchemas] reflects distinct variants instead.
+// Like nullable pointers, the $refs of extracted schemas are wrapped in an `allOf`, so the flag does not change the shared schema.
+func markAccessModes() customizerPipe {
+	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
+		modes, err := getAccessModes(t)
+		if err != nil {
+			return true, err
+		}
+		for prop, mode := range modes {
+			ref, ok := schema.Properties[prop]
+			if !ok || ref.Value == nil {
+				continue
+			}
+			if _, extracted := ref.Value.Extensions["$id"]; extracted || strings.HasPrefix(ref.Ref, "#/components/schemas/") {
+				wrapper := openapi3.NewSchema()
+				wrapper.AllOf = openapi3.SchemaRefs{ref}
+				ref = openapi3.NewSchemaRef("", wrapper)
+				schema.Properties[prop] = ref
+			}
+			ref.Value.ReadOnly = mode == "readOnly"
+			ref.Value.WriteOnly = mode == "writeOnly"
+		}
+		return
+	}
+}
+
+// getAccessModes returns the access modes declared in the `openapi` tags of the fields of the struct `t` by their json names,
+// like [getPointerProps]
+func getAccessModes(t reflect.Type) (map[string]string, error) {
+	if t.Kind() == reflect.Pointer {
+		return getAccessModes(t.Elem())
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	modes := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous {
+			embedded, err := getAccessModes(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			for prop, mode := range embedded {
+				modes[prop] = mode
+			}
+			continue
+		}
+
+		mode, ok := f.Tag.Lookup("openapi")
+		name, _ := jsonName(f)
+		if !ok || name == "-" {
+			continue
+		}
+		if mode != "readOnly" && mode != "writeOnly" {
+			return nil, fmt.Errorf("invalid openapi tag `%s` of %s.%s: expected readOnly or writeOnly", mode, t, f.Name)
+		}
+		modes[name] = mode
+	}
+	return modes, nil
+}
+
 // markPropertiesRequired flags a schema property as required unless the json struct tag defines `omitempty`
 func markPropertiesRequired() customizerPipe {
 	return func(name string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) (stop bool, err error) {
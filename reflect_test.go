@@ -2,10 +2,14 @@ package expose
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3gen"
@@ -61,6 +65,18 @@ func TestReflection(t *testing.T) {
 		g.Eq(s.Ref, "#/components/schemas/stringList")
 	})
 
+	t.Run("struct list", func(t *testing.T) {
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema([]*dup{}, schemas, reflectSettings{mapper: mapper, typeNamer: DefaultSchemaIdentifier})
+		g.Must().Nil(err)
+
+		g.Eq(s.Ref, "")
+		g.Eq(s.Value.Type, &openapi3.Types{openapi3.TypeArray})
+		g.Eq(s.Value.Items.Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+		g.NotZero(schemas["github.com.pbedat.expose.dup"])
+		g.Eq(schemas["github.com.pbedat.expose.dupList"], nil)
+	})
+
 	t.Run("dedup", func(t *testing.T) {
 		schemas := openapi3.Schemas{}
 		settings := reflectSettings{mapper: mapper, typeNamer: DefaultSchemaIdentifier}
@@ -196,3 +212,736 @@ func TestRequired(t *testing.T) {
 		g.Eq(actual, expected)
 	})
 }
+
+func TestReflectSecurity(t *testing.T) {
+	g := got.T(t)
+
+	actual, err := ReflectSpec(openapi3.T{}, []Function{
+		FuncNullary("/admin/stats", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}, RequireSecurity("bearer", "admin")),
+		FuncNullary("/public/stats", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	g.Eq(*actual.Paths.Find("/admin/stats").Post.Security, openapi3.SecurityRequirements{
+		{"bearer": []string{"admin"}},
+	})
+	g.Nil(actual.Paths.Find("/public/stats").Post.Security)
+}
+
+func TestReflectSpecKeepsComponents(t *testing.T) {
+	g := got.T(t)
+
+	components := openapi3.NewComponents()
+	components.Schemas = openapi3.Schemas{"custom": openapi3.NewSchemaRef("", openapi3.NewStringSchema())}
+
+	root := openapi3.T{Components: &components}
+	actual, err := ReflectSpec(root, []Function{
+		FuncNullary("/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	g.NotZero(actual.Components.Schemas["custom"])
+	g.NotZero(actual.Components.Schemas["int"])
+	g.Len(root.Components.Schemas, 1)
+}
+
+type status int
+
+const (
+	statusActive status = iota + 1
+	statusBlocked
+)
+
+func TestRegisterIntEnum(t *testing.T) {
+	g := got.T(t)
+
+	type account struct {
+		Status status
+	}
+
+	actual, err := ReflectSpec(openapi3.T{}, []Function{
+		FuncNullary("/accounts/get", func(ctx context.Context) (account, error) {
+			return account{}, nil
+		}),
+	}, RegisterIntEnum(map[status]string{
+		statusBlocked: "StatusBlocked",
+		statusActive:  "StatusActive",
+	}))
+	g.Must().Nil(err)
+
+	s := actual.Components.Schemas["github.com.pbedat.expose.account"].Value.Properties["Status"].Value
+
+	g.Eq(s.Enum, []any{float64(1), float64(2)})
+	g.Eq(s.Extensions["x-enum-varnames"], []string{"StatusActive", "StatusBlocked"})
+}
+
+func TestReflectErrorResponses(t *testing.T) {
+	g := got.T(t)
+
+	type apiError struct {
+		Message string `json:"message"`
+		Code    string `json:"code,omitempty"`
+	}
+
+	actual, err := ReflectSpec(openapi3.T{}, []Function{
+		FuncNullary("/users/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}, ErrorResponse(404, "user not found")),
+	}, ReflectErrorSchema(apiError{}))
+	g.Must().Nil(err)
+
+	g.NotZero(actual.Components.Schemas["github.com.pbedat.expose.apiError"])
+
+	responses := actual.Paths.Find("/users/get").Post.Responses
+	for _, status := range []string{"4XX", "5XX", "404"} {
+		g.Eq(responses.Value(status).Value.Content.Get("application/json").Schema.Ref,
+			"#/components/schemas/github.com.pbedat.expose.apiError")
+	}
+	g.Eq(*responses.Value("404").Value.Description, "user not found")
+}
+
+func TestOperationID(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+	}
+
+	actual, err := ReflectSpec(openapi3.T{}, fns)
+	g.Must().Nil(err)
+	g.Eq(actual.Paths.Find("/counter/get").Post.OperationID, "counter#get")
+
+	actual, err = ReflectSpec(openapi3.T{}, fns, WithOperationID(func(fn Function) string {
+		return fn.Module() + "_" + fn.Name()
+	}))
+	g.Must().Nil(err)
+	g.Eq(actual.Paths.Find("/counter/get").Post.OperationID, "counter_get")
+}
+
+func TestReflectSpecDeterministic(t *testing.T) {
+	g := got.T(t)
+
+	type tag struct{ Name string }
+	type owner1 struct{ A string }
+	type owner2 struct{ B string }
+	type item struct {
+		Tags   []tag
+		Owner  owner1
+		Parent *owner2
+	}
+
+	fns := []Function{
+		Func("/items/save", func(ctx context.Context, i item) (item, error) {
+			return i, nil
+		}),
+		FuncNullary("/items/list", func(ctx context.Context) ([]item, error) {
+			return nil, nil
+		}),
+	}
+
+	// owner1 and owner2 share an identifier, so the order of the extraction decides, which schema is kept
+	namer := func(t reflect.Type) string {
+		return strings.TrimRight(DefaultSchemaIdentifier(t), "0123456789")
+	}
+
+	var expected []byte
+	for i := 0; i < 20; i++ {
+		spec, err := ReflectSpec(openapi3.T{}, fns, WithSchemaIdentifier(namer))
+		g.Must().Nil(err)
+
+		actual, err := json.Marshal(spec)
+		g.Must().Nil(err)
+
+		if expected == nil {
+			expected = actual
+			continue
+		}
+		g.Must().Eq(string(actual), string(expected))
+	}
+}
+
+func TestReflectListResponse(t *testing.T) {
+	g := got.T(t)
+
+	actual, err := ReflectSpec(openapi3.T{}, []Function{
+		FuncNullary("/dups/list", func(ctx context.Context) ([]dup, error) {
+			return nil, nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	schema := actual.Paths.Find("/dups/list").Post.Responses.Status(200).Value.Content.Get("application/json").Schema
+	g.Eq(schema.Value.Type, &openapi3.Types{openapi3.TypeArray})
+	g.Eq(schema.Value.Items.Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+	g.NotZero(actual.Components.Schemas["github.com.pbedat.expose.dup"])
+}
+
+func TestReflectMaps(t *testing.T) {
+	var mapper SchemaMapper = func(t reflect.Type) *openapi3.Schema {
+		return nil
+	}
+	settings := reflectSettings{mapper: mapper, typeNamer: DefaultSchemaIdentifier}
+
+	t.Run("primitive values", func(t *testing.T) {
+		g := got.T(t)
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema(map[string]int{}, schemas, settings)
+		g.Must().Nil(err)
+
+		g.Eq(s.Ref, "")
+		g.Eq(s.Value.Type, &openapi3.Types{openapi3.TypeObject})
+		g.Eq(s.Value.AdditionalProperties.Schema.Value.Type, &openapi3.Types{openapi3.TypeInteger})
+		g.Len(schemas, 0)
+	})
+
+	t.Run("struct values", func(t *testing.T) {
+		g := got.T(t)
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema(map[string]dup{}, schemas, settings)
+		g.Must().Nil(err)
+
+		g.Eq(s.Value.AdditionalProperties.Schema.Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+		g.NotZero(schemas["github.com.pbedat.expose.dup"])
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		g := got.T(t)
+		type index struct {
+			Entries map[string]dup
+		}
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema(index{}, schemas, settings)
+		g.Must().Nil(err)
+
+		actual := schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		g.Eq(actual.Value.Properties["Entries"].Value.AdditionalProperties.Schema.Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+		g.NotZero(schemas["github.com.pbedat.expose.dup"])
+	})
+
+	t.Run("non string keys", func(t *testing.T) {
+		g := got.T(t)
+		type index struct {
+			Entries map[int]dup
+		}
+
+		_, err := reflectSchema(map[int]string{}, openapi3.Schemas{}, settings)
+		g.Has(err.Error(), "only string keys are supported")
+
+		_, err = reflectSchema(index{}, openapi3.Schemas{}, settings)
+		g.Has(err.Error(), "only string keys are supported")
+	})
+}
+
+func TestReflectPointers(t *testing.T) {
+	g := got.T(t)
+
+	type profile struct {
+		Name     string
+		Nickname *string
+		Age      *int `required:"true"`
+		Friend   *dup
+		Best     dup
+	}
+
+	schemas := openapi3.Schemas{}
+	s, err := reflectSchema(profile{}, schemas, reflectSettings{
+		mapper:    func(t reflect.Type) *openapi3.Schema { return nil },
+		typeNamer: DefaultSchemaIdentifier,
+	})
+	g.Must().Nil(err)
+
+	actual := schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")].Value
+
+	g.Eq(actual.Required, []string{"Name", "Age", "Best"})
+
+	g.Eq(actual.Properties["Name"].Value.Nullable, false)
+	g.Eq(actual.Properties["Nickname"].Value.Nullable, true)
+	g.Eq(actual.Properties["Age"].Value.Nullable, true)
+
+	g.Eq(actual.Properties["Friend"].Value.Nullable, true)
+	g.Eq(actual.Properties["Friend"].Value.AllOf[0].Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+	g.Eq(actual.Properties["Best"].Ref, "#/components/schemas/github.com.pbedat.expose.dup")
+	g.Eq(schemas["github.com.pbedat.expose.dup"].Value.Nullable, false)
+}
+
+func TestReflectFormat(t *testing.T) {
+	g := got.T(t)
+
+	type contact struct {
+		Email    string  `format:"email"`
+		Homepage *string `format:"uri"`
+		Name     string
+	}
+
+	schemas := openapi3.Schemas{}
+	s, err := reflectSchema(contact{}, schemas, reflectSettings{
+		mapper:    func(t reflect.Type) *openapi3.Schema { return nil },
+		typeNamer: DefaultSchemaIdentifier,
+	})
+	g.Must().Nil(err)
+
+	actual := schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")].Value
+	g.Eq(actual.Properties["Email"].Value.Format, "email")
+	g.Eq(actual.Properties["Homepage"].Value.Format, "uri")
+	g.Eq(actual.Properties["Name"].Value.Format, "")
+}
+
+func TestReflectConstraints(t *testing.T) {
+	settings := reflectSettings{
+		mapper:    func(t reflect.Type) *openapi3.Schema { return nil },
+		typeNamer: DefaultSchemaIdentifier,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		g := got.T(t)
+
+		type product struct {
+			Quantity int               `validate:"min=1,max=100"`
+			Name     string            `validate:"minLength=3,maxLength=20"`
+			SKU      string            `validate:"minLength=1,pattern=^[A-Z]{2,4}-[0-9]+$"`
+			Color    string            `validate:"enum=red|green"`
+			Size     float64           `validate:"enum=1|1.5,required"`
+			Code     string            `validate:"min=3,max=5"`
+			Tags     []string          `validate:"min=1,max=3"`
+			Labels   map[string]string `validate:"max=2"`
+			Rating   *int              `validate:"enum=1|2"`
+		}
+
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema(product{}, schemas, settings)
+		g.Must().Nil(err)
+
+		actual := schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")].Value
+
+		quantity := actual.Properties["Quantity"].Value
+		g.Eq(*quantity.Min, 1.0)
+		g.Eq(*quantity.Max, 100.0)
+
+		name := actual.Properties["Name"].Value
+		g.Eq(name.MinLength, uint64(3))
+		g.Eq(*name.MaxLength, uint64(20))
+
+		sku := actual.Properties["SKU"].Value
+		g.Eq(sku.MinLength, uint64(1))
+		g.Eq(sku.Pattern, "^[A-Z]{2,4}-[0-9]+$")
+
+		g.Eq(actual.Properties["Color"].Value.Enum, []any{"red", "green"})
+		g.Eq(actual.Properties["Size"].Value.Enum, []any{1.0, 1.5})
+
+		// min and max limit the length of strings, arrays and maps
+		code := actual.Properties["Code"].Value
+		g.Nil(code.Min)
+		g.Eq(code.MinLength, uint64(3))
+		g.Eq(*code.MaxLength, uint64(5))
+		tags := actual.Properties["Tags"].Value
+		g.Eq(tags.MinItems, uint64(1))
+		g.Eq(*tags.MaxItems, uint64(3))
+		g.Eq(*actual.Properties["Labels"].Value.MaxProps, uint64(2))
+
+		g.Eq(actual.Properties["Rating"].Value.Enum, []any{1.0, 2.0})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		g := got.T(t)
+
+		type product struct {
+			Quantity int `validate:"min=one"`
+		}
+
+		_, err := reflectSchema(product{}, openapi3.Schemas{}, settings)
+		g.Has(err.Error(), "invalid constraints `min=one` of Quantity")
+
+		type user struct {
+			Name string `validate:"min=1.5"`
+		}
+
+		_, err = reflectSchema(user{}, openapi3.Schemas{}, settings)
+		g.Has(err.Error(), "invalid constraints `min=1.5` of Name")
+	})
+}
+
+func TestReflectDefault(t *testing.T) {
+	settings := reflectSettings{
+		mapper:    func(t reflect.Type) *openapi3.Schema { return nil },
+		typeNamer: DefaultSchemaIdentifier,
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		g := got.T(t)
+
+		type query struct {
+			Limit   int      `json:"limit,omitempty" default:"10"`
+			Offset  *uint    `json:"offset,omitempty" default:"0"`
+			Sort    string   `json:"sort,omitempty" default:"name"`
+			Desc    bool     `json:"desc,omitempty" default:"true"`
+			Ratio   float64  `json:"ratio,omitempty" default:"0.5"`
+			Fields  []string `json:"fields,omitempty" default:"[\"id\",\"name\"]"`
+			Filters map[string]int
+		}
+
+		schemas := openapi3.Schemas{}
+		s, err := reflectSchema(query{}, schemas, settings)
+		g.Must().Nil(err)
+
+		actual := schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")].Value
+		g.Eq(actual.Properties["limit"].Value.Default, int64(10))
+		g.Eq(actual.Properties["offset"].Value.Default, uint64(0))
+		g.Eq(actual.Properties["sort"].Value.Default, "name")
+		g.Eq(actual.Properties["desc"].Value.Default, true)
+		g.Eq(actual.Properties["ratio"].Value.Default, 0.5)
+		g.Eq(actual.Properties["fields"].Value.Default, []string{"id", "name"})
+		g.Nil(actual.Properties["Filters"].Value.Default)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		g := got.T(t)
+
+		type query struct {
+			Limit int `default:"ten"`
+		}
+
+		_, err := reflectSchema(query{}, openapi3.Schemas{}, settings)
+		g.Has(err.Error(), "invalid default `ten` of Limit")
+	})
+}
+
+func TestSchemaName(t *testing.T) {
+	g := got.T(t)
+
+	actual, err := ReflectSpec(openapi3.T{}, []Function{
+		Func("/dedup/save", func(ctx context.Context, d dedup1) (*dup, error) {
+			return nil, nil
+		}),
+	}, WithSchemaName(reflect.TypeOf(dup{}), "Dup"))
+	g.Must().Nil(err)
+
+	g.NotZero(actual.Components.Schemas["Dup"])
+	g.NotZero(actual.Components.Schemas["github.com.pbedat.expose.dedup1"])
+	g.Eq(actual.Components.Schemas["github.com.pbedat.expose.dup"], nil)
+
+	g.Eq(actual.Components.Schemas["github.com.pbedat.expose.dedup1"].Value.Properties["Dup1"].Ref, "#/components/schemas/Dup")
+	g.Eq(actual.Paths.Find("/dedup/save").Post.Responses.Status(200).Value.Content.Get("application/json").Schema.Ref, "#/components/schemas/Dup")
+}
+
+type node struct {
+	Name     string
+	Children []node
+}
+
+type linkedList struct {
+	Value int
+	Next  *linkedList
+}
+
+type forest struct {
+	Trees []tree
+}
+
+type tree struct {
+	Root branch
+}
+
+type branch struct {
+	Tree *tree
+}
+
+func TestReflectRecursive(t *testing.T) {
+	nodeID := "github.com.pbedat.expose.node"
+
+	t.Run("tree", func(t *testing.T) {
+		g := got.T(t)
+
+		actual, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncNullary("/nodes/get", func(ctx context.Context) (node, error) {
+				return node{}, nil
+			}),
+		})
+		g.Must().Nil(err)
+
+		g.Len(actual.Components.Schemas, 1)
+		schema := actual.Components.Schemas[nodeID].Value
+		g.Eq(schema.Properties["Children"].Value.Items.Ref, "#/components/schemas/"+nodeID)
+		g.Eq(schema.Nullable, false)
+	})
+
+	t.Run("linked list", func(t *testing.T) {
+		g := got.T(t)
+
+		actual, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncNullary("/lists/get", func(ctx context.Context) (linkedList, error) {
+				return linkedList{}, nil
+			}),
+		})
+		g.Must().Nil(err)
+
+		schema := actual.Components.Schemas["github.com.pbedat.expose.linkedList"].Value
+		next := schema.Properties["Next"].Value
+		g.Eq(next.Nullable, true)
+		g.Eq(next.AllOf[0].Ref, "#/components/schemas/github.com.pbedat.expose.linkedList")
+	})
+
+	t.Run("mutual recursion", func(t *testing.T) {
+		g := got.T(t)
+
+		actual, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncNullary("/forests/get", func(ctx context.Context) (forest, error) {
+				return forest{}, nil
+			}),
+		})
+		g.Must().Nil(err)
+
+		schemas := actual.Components.Schemas
+		g.Eq(schemas["github.com.pbedat.expose.forest"].Value.Properties["Trees"].Value.Items.Ref, "#/components/schemas/github.com.pbedat.expose.tree")
+		g.Eq(schemas["github.com.pbedat.expose.tree"].Value.Properties["Root"].Ref, "#/components/schemas/github.com.pbedat.expose.branch")
+		g.Eq(schemas["github.com.pbedat.expose.branch"].Value.Properties["Tree"].Value.AllOf[0].Ref, "#/components/schemas/github.com.pbedat.expose.tree")
+	})
+
+	t.Run("dereferenced", func(t *testing.T) {
+		g := got.T(t)
+
+		spec, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncNullary("/nodes/get", func(ctx context.Context) (node, error) {
+				return node{}, nil
+			}),
+		})
+		g.Must().Nil(err)
+
+		actual, err := dereferenceSpec(spec)
+		g.Must().Nil(err)
+
+		schema := actual.Paths.Find("/nodes/get").Post.Responses.Status(200).Value.Content.Get("application/json").Schema
+		g.Eq(schema.Ref, "")
+		g.Eq(schema.Value.Properties["Children"].Value.Items.Ref, "#/components/schemas/"+nodeID)
+	})
+}
+
+func TestModuleFunc(t *testing.T) {
+	g := got.T(t)
+
+	firstSegment := func(path string) string {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		return segment
+	}
+	fns := []Function{
+		FuncNullary("/app/commands/inc", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}),
+		FuncNullary("/app/queries/get", func(ctx context.Context) (int, error) {
+			return 0, nil
+		}, WithTags("reads")),
+	}
+
+	spec, err := ReflectSpec(openapi3.T{}, fns, WithModuleFunc(firstSegment))
+	g.Must().Nil(err)
+
+	inc := spec.Paths.Find("/app/commands/inc").Post
+	g.Eq(inc.Tags, []string{"app"})
+	g.Eq(inc.OperationID, "app#inc")
+	get := spec.Paths.Find("/app/queries/get").Post
+	g.Eq(get.Tags, []string{"reads"})
+	g.Eq(get.OperationID, "app#get")
+
+	h, err := NewHandler(fns, WithReflection(WithModuleFunc(firstSegment)))
+	g.Must().Nil(err)
+	g.Eq(h.Functions()[0].Module(), "app")
+	g.Eq(fns[0].Module(), "app.commands")
+}
+
+type nullableAddress struct {
+	City string `json:"city"`
+}
+
+type nullableCustomer struct {
+	Nickname *string          `json:"nickname"`
+	Address  *nullableAddress `json:"address"`
+}
+
+func TestOpenAPIVersion(t *testing.T) {
+	fns := []Function{
+		Func("/customers/save", func(ctx context.Context, req nullableCustomer) (Void, error) {
+			return Void{}, nil
+		}, Validate(true)),
+	}
+
+	customerSchema := func(spec openapi3.T) *openapi3.Schema {
+		return spec.Components.Schemas[DefaultSchemaIdentifier(reflect.TypeOf(nullableCustomer{}))].Value
+	}
+
+	t.Run("default", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := ReflectSpec(openapi3.T{}, fns)
+		g.Must().Nil(err)
+
+		g.Eq(spec.OpenAPI, "3.0.2")
+		g.True(customerSchema(spec).Properties["nickname"].Value.Nullable)
+		g.True(customerSchema(spec).Properties["address"].Value.Nullable)
+	})
+
+	t.Run("3.1", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := ReflectSpec(openapi3.T{}, fns, WithOpenAPIVersion("3.1.0"))
+		g.Must().Nil(err)
+
+		g.Eq(spec.OpenAPI, "3.1.0")
+
+		nickname := customerSchema(spec).Properties["nickname"].Value
+		g.False(nickname.Nullable)
+		g.Eq(nickname.Type.Slice(), []string{"string", "null"})
+
+		address := customerSchema(spec).Properties["address"].Value
+		g.False(address.Nullable)
+		g.Len(address.AnyOf, 2)
+		g.Eq(address.AnyOf[0].Ref, "#/components/schemas/"+DefaultSchemaIdentifier(reflect.TypeOf(nullableAddress{})))
+		g.Eq(address.AnyOf[1].Value.Type.Slice(), []string{"null"})
+
+		h, err := NewHandler(fns, WithReflection(WithOpenAPIVersion("3.1.0")))
+		g.Must().Nil(err)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/customers/save", strings.NewReader(`{"nickname":null,"address":null}`))
+		r.Header.Set("content-type", "application/json")
+		h.ServeHTTP(w, r)
+		g.Eq(w.Code, http.StatusOK)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		g := got.T(t)
+		_, err := ReflectSpec(openapi3.T{}, fns, WithOpenAPIVersion("2.0"))
+		g.Has(err.Error(), "unsupported openapi version '2.0'")
+	})
+}
+
+func TestExtensions(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		FuncNullary("/orders/list", func(ctx context.Context) ([]string, error) {
+			return nil, nil
+		}, WithExtension("x-owner", "team-orders"), WithExtension("x-sla", map[string]any{"p99": "200ms"})),
+	}
+
+	h, err := NewHandler(fns, WithSpecExtension("x-api-id", "orders"))
+	g.Must().Nil(err)
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+
+	data, err := json.Marshal(&spec)
+	g.Must().Nil(err)
+
+	var doc struct {
+		APIID string `json:"x-api-id"`
+		Paths map[string]struct {
+			Post map[string]any `json:"post"`
+		} `json:"paths"`
+	}
+	g.Must().Nil(json.Unmarshal(data, &doc))
+
+	g.Eq(doc.APIID, "orders")
+	op := doc.Paths["/orders/list"].Post
+	g.Eq(op["x-owner"], "team-orders")
+	g.Eq(op["x-sla"], map[string]any{"p99": "200ms"})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		g := got.T(t)
+
+		_, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncNullary("/orders/list", func(ctx context.Context) ([]string, error) {
+				return nil, nil
+			}, WithExtension("owner", "team-orders")),
+		})
+		g.Has(err.Error(), "the extension 'owner' must start with 'x-'")
+
+		_, err = NewHandler(fns, WithSpecExtension("api-id", "orders"))
+		g.Has(err.Error(), "the extension 'api-id' must start with 'x-'")
+	})
+}
+
+func TestReflectBasePath(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/inc", func(ctx context.Context) (int, error) {
+			return 1, nil
+		}),
+	}
+
+	t.Run("server", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := ReflectSpec(openapi3.T{}, fns, WithBasePath("/rpc/"))
+		g.Must().Nil(err)
+		g.Eq(spec.Servers[0].URL, "/rpc")
+		g.NotNil(spec.Paths.Find("/counter/inc"))
+	})
+
+	t.Run("servers of the root spec", func(t *testing.T) {
+		g := got.T(t)
+		root := openapi3.T{Servers: openapi3.Servers{{URL: "https://example.com/rpc"}}}
+		spec, err := ReflectSpec(root, fns, WithBasePath("/rpc"))
+		g.Must().Nil(err)
+		g.Len(spec.Servers, 1)
+		g.Eq(spec.Servers[0].URL, "https://example.com/rpc")
+	})
+
+	t.Run("path prefix", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns, WithPathPrefix("/rpc"))
+		g.Must().Nil(err)
+		g.Eq(spec.Servers[0].URL, "/rpc")
+	})
+}
+
+func TestReflectAccessModes(t *testing.T) {
+	type audit struct {
+		CreatedAt time.Time `json:"createdAt" openapi:"readOnly"`
+	}
+	type account struct {
+		audit
+		ID       string   `json:"id" openapi:"readOnly"`
+		Name     string   `json:"name"`
+		Password string   `json:"password,omitempty" openapi:"writeOnly"`
+		Owner    *account `json:"owner" openapi:"readOnly"`
+		Parent   node     `json:"parent" openapi:"readOnly"`
+	}
+
+	g := got.T(t)
+	spec, err := ReflectSpec(openapi3.T{}, []Function{
+		Func("/accounts/save", func(ctx context.Context, a account) (account, error) {
+			return a, nil
+		}),
+	})
+	g.Must().Nil(err)
+
+	schema := spec.Components.Schemas["github.com.pbedat.expose.account"].Value
+	g.True(schema.Properties["id"].Value.ReadOnly)
+	g.True(schema.Properties["createdAt"].Value.ReadOnly)
+	g.True(schema.Properties["password"].Value.WriteOnly)
+	g.False(schema.Properties["name"].Value.ReadOnly)
+	// read-only fields remain required, which only applies to responses
+	g.Has(schema.Required, "id")
+
+	// the shared schemas of the extracted types are not flagged
+	g.True(schema.Properties["owner"].Value.ReadOnly)
+	g.True(schema.Properties["owner"].Value.Nullable)
+	g.True(schema.Properties["parent"].Value.ReadOnly)
+	g.Eq(schema.Properties["parent"].Value.AllOf[0].Ref, "#/components/schemas/github.com.pbedat.expose.node")
+	g.False(spec.Components.Schemas["github.com.pbedat.expose.node"].Value.ReadOnly)
+
+	t.Run("invalid tag", func(t *testing.T) {
+		g := got.T(t)
+		type invalid struct {
+			ID string `openapi:"hidden"`
+		}
+		_, err := ReflectSpec(openapi3.T{}, []Function{
+			FuncVoid("/invalid", func(ctx context.Context, v invalid) error {
+				return nil
+			}),
+		})
+		g.Has(err.Error(), "invalid openapi tag `hidden`")
+	})
+}
@@ -0,0 +1,55 @@
+package expose
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// maxRequestIDLength limits the length of incoming request ids. Longer ids are replaced with a generated one.
+const maxRequestIDLength = 128
+
+// WithRequestID assigns an id to every call of an exposed function. The id is taken from the request header `header`
+// (e.g. "X-Request-Id") or generated, when the header is missing.
+// The id is echoed in the response header `header` and included in error responses as `requestId`.
+// Functions, [AuthFunc]s and [LogFunc]s obtain it with [RequestID].
+func WithRequestID(header string) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.requestIDHeader = header
+	}
+}
+
+// RequestID returns the id of the request, that is assigned when [WithRequestID] is enabled.
+// It returns an empty string, when `ctx` carries no id.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// assignRequestID returns a copy of `ctx`, that carries the id of the request `r` and echoes the id in the response header.
+// It returns `ctx` unchanged, when [WithRequestID] is not enabled.
+func (settings *handlerSettings) assignRequestID(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
+	if settings.requestIDHeader == "" {
+		return ctx
+	}
+
+	id := r.Header.Get(settings.requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		id = newRequestID()
+	}
+	w.Header().Set(settings.requestIDHeader, id)
+
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// newRequestID generates a random request id of 32 hex digits
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,80 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestRequestID(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/id", func(ctx context.Context) (string, error) {
+			return RequestID(ctx), nil
+		}),
+		FuncNullaryVoid("/fail", func(ctx context.Context) error {
+			return errors.New("failed")
+		}),
+	}
+	h, err := NewHandler(fns, WithRequestID("X-Request-Id"))
+	got.T(t).Must().Nil(err)
+
+	t.Run("incoming id", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/id", nil)
+		r.Header.Set("X-Request-Id", "abc")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("X-Request-Id"), "abc")
+		var id string
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &id))
+		g.Eq(id, "abc")
+	})
+
+	t.Run("generated id", func(t *testing.T) {
+		g := got.T(t)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/id", nil))
+
+		id := w.Header().Get("X-Request-Id")
+		g.Len(id, 32)
+		var actual string
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &actual))
+		g.Eq(actual, id)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		g := got.T(t)
+		r := httptest.NewRequest(http.MethodPost, "/fail", nil)
+		r.Header.Set("X-Request-Id", "abc")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusInternalServerError)
+		g.Eq(w.Header().Get("X-Request-Id"), "abc")
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Eq(body["requestId"], "abc")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/fail", nil))
+
+		g.Eq(w.Header().Get("X-Request-Id"), "")
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		_, ok := body["requestId"]
+		g.False(ok)
+	})
+}
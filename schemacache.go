@@ -0,0 +1,130 @@
+package expose
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SchemaCache memoizes the reflected schemas of types, so that types shared by many functions
+// or handlers are only reflected once. See [WithSchemaCache].
+//
+// The cached schemas depend on the reflection options (schema mapper, identifiers, enums...).
+// Share a cache only between specs, that are reflected with the same options.
+// It is safe for concurrent use.
+type SchemaCache struct {
+	mu      sync.Mutex
+	entries map[schemaCacheKey]schemaCacheEntry
+}
+
+type schemaCacheKey struct {
+	t reflect.Type
+	// inline schemas are not extracted into the components/schemas, see [SkipExtractSubSchemas]
+	inline bool
+}
+
+type schemaCacheEntry struct {
+	ref *openapi3.SchemaRef
+	// subSchemas are the schemas, that were extracted into the components/schemas
+	subSchemas openapi3.Schemas
+}
+
+// NewSchemaCache creates an empty [SchemaCache]
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{entries: map[schemaCacheKey]schemaCacheEntry{}}
+}
+
+// WithSchemaCache reflects the schemas of types with the help of the cache `c`.
+// Use the same cache for multiple handlers or calls of [ReflectSpec], that share types.
+// Every spec receives copies of the cached schemas, so the specs can be post-processed independently.
+func WithSchemaCache(c *SchemaCache) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.cache = c
+	}
+}
+
+// generate returns a copy of the cached schema of the type of `val` or generates and caches it with `generate`.
+// Copies of the sub schemas of the type are added to `schemas`.
+func (c *SchemaCache) generate(val any, inline bool, schemas openapi3.Schemas, generate func(schemas openapi3.Schemas) (*openapi3.SchemaRef, error)) (*openapi3.SchemaRef, error) {
+	key := schemaCacheKey{reflect.TypeOf(val), inline}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		subSchemas := openapi3.Schemas{}
+		ref, err := generate(subSchemas)
+		if err != nil {
+			return nil, err
+		}
+		entry = schemaCacheEntry{ref, subSchemas}
+
+		c.mu.Lock()
+		c.entries[key] = entry
+		c.mu.Unlock()
+	}
+
+	// the schemas are copied, since the callers complete them in place, e.g. with the schemas of envelopes
+	copied := map[*openapi3.Schema]*openapi3.Schema{}
+	for id, s := range entry.subSchemas {
+		if _, ok := schemas[id]; !ok {
+			schemas[id] = copySchemaRef(s, copied)
+		}
+	}
+	return copySchemaRef(entry.ref, copied), nil
+}
+
+// copySchemaRef deeply copies `ref` with its sub schemas. Unchanged values, e.g. enums and defaults, are shared.
+// `copied` maps the schemas to their copies, so that schemas, that are referenced multiple times, are copied once.
+func copySchemaRef(ref *openapi3.SchemaRef, copied map[*openapi3.Schema]*openapi3.Schema) *openapi3.SchemaRef {
+	if ref == nil {
+		return nil
+	}
+	copiedRef := *ref
+	if ref.Value == nil {
+		return &copiedRef
+	}
+	if s, ok := copied[ref.Value]; ok {
+		copiedRef.Value = s
+		return &copiedRef
+	}
+
+	s := *ref.Value
+	copied[ref.Value] = &s
+	copiedRef.Value = &s
+
+	copyRefs := func(refs openapi3.SchemaRefs) openapi3.SchemaRefs {
+		if refs == nil {
+			return nil
+		}
+		copies := make(openapi3.SchemaRefs, len(refs))
+		for i, ref := range refs {
+			copies[i] = copySchemaRef(ref, copied)
+		}
+		return copies
+	}
+	s.OneOf = copyRefs(s.OneOf)
+	s.AnyOf = copyRefs(s.AnyOf)
+	s.AllOf = copyRefs(s.AllOf)
+	s.Not = copySchemaRef(s.Not, copied)
+	s.Items = copySchemaRef(s.Items, copied)
+	s.AdditionalProperties.Schema = copySchemaRef(s.AdditionalProperties.Schema, copied)
+	if s.Properties != nil {
+		s.Properties = make(openapi3.Schemas, len(ref.Value.Properties))
+		for name, prop := range ref.Value.Properties {
+			s.Properties[name] = copySchemaRef(prop, copied)
+		}
+	}
+	if s.Type != nil {
+		types := slices.Clone(*s.Type)
+		s.Type = &types
+	}
+	s.Required = slices.Clone(s.Required)
+	s.Enum = slices.Clone(s.Enum)
+	s.Extensions = maps.Clone(s.Extensions)
+	return &copiedRef
+}
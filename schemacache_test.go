@@ -0,0 +1,113 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+type cachedAddress struct {
+	City string
+}
+
+type cachedCustomer struct {
+	Name    string
+	Address cachedAddress
+}
+
+func TestSchemaCache(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		Func("/customers/create", func(ctx context.Context, c cachedCustomer) (cachedCustomer, error) {
+			return c, nil
+		}),
+		FuncNullary("/customers/list", func(ctx context.Context) ([]cachedCustomer, error) {
+			return nil, nil
+		}),
+	}
+
+	reflected := 0
+	mapper := WithSchemaMapper(func(t reflect.Type) *openapi3.Schema {
+		if t == reflect.TypeOf(cachedCustomer{}) {
+			reflected++
+		}
+		return nil
+	})
+
+	uncached, err := ReflectSpec(openapi3.T{}, fns, mapper)
+	g.Must().Nil(err)
+
+	reflected = 0
+	cache := NewSchemaCache()
+	first, err := ReflectSpec(openapi3.T{}, fns, mapper, WithSchemaCache(cache))
+	g.Must().Nil(err)
+	second, err := ReflectSpec(openapi3.T{}, fns, mapper, WithSchemaCache(cache))
+	g.Must().Nil(err)
+
+	// the type is reflected once for the spec
+	g.Eq(reflected, 1)
+
+	expected, err := json.Marshal(uncached)
+	g.Must().Nil(err)
+	for _, spec := range []openapi3.T{first, second} {
+		actual, err := json.Marshal(spec)
+		g.Must().Nil(err)
+		g.Eq(string(actual), string(expected))
+	}
+}
+
+type cachedResA struct {
+	A string `json:"a"`
+}
+
+type cachedResB struct {
+	B int `json:"b"`
+}
+
+func TestSchemaCacheEnvelope(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/a", func(ctx context.Context) (cachedResA, error) {
+			return cachedResA{A: "a"}, nil
+		}),
+		FuncNullary("/b", func(ctx context.Context) (cachedResB, error) {
+			return cachedResB{B: 1}, nil
+		}),
+	}
+	opts := []HandlerOption{
+		WithReflection(WithSchemaCache(NewSchemaCache())),
+		WithResponseEnvelope(func(res any) any {
+			return envelope{Data: res}
+		}),
+		WithResponseValidation(true),
+	}
+
+	g := got.T(t)
+	h, err := NewHandler(fns, opts...)
+	g.Must().Nil(err)
+
+	spec, err := h.Spec()
+	g.Must().Nil(err)
+	for path, id := range map[string]string{"/a": "cachedResA", "/b": "cachedResB"} {
+		schema := spec.Paths.Find(path).Post.Responses.Status(http.StatusOK).Value.Content.Get("application/json").Schema
+		g.Has(schema.Value.Properties["data"].Ref, id)
+	}
+
+	for _, path := range []string{"/a", "/b"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		g.Eq(w.Code, http.StatusOK)
+	}
+
+	// the cached schemas are not changed by the specs
+	again, err := BuildSpec(fns, opts...)
+	g.Must().Nil(err)
+	schema := again.Paths.Find("/a").Post.Responses.Status(http.StatusOK).Value.Content.Get("application/json").Schema
+	g.Has(schema.Value.Properties["data"].Ref, "cachedResA")
+}
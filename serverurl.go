@@ -0,0 +1,71 @@
+package expose
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// WithRequestServerURL sets the url of the first server of the served spec (see [WithDefaultSpec]) to the url,
+// that the handler is reached at by the request of the spec. The url is composed of the scheme, the host and the base path
+// (see [WithPathPrefix]) of the request. When the handler is behind a proxy, the `X-Forwarded-Proto`, `X-Forwarded-Host` and
+// `X-Forwarded-Prefix` headers take precedence, so only enable it when the proxy sets or removes them.
+//
+// The url is resolved per request for the spec and the swagger UI, the spec of [Handler.Spec] is not changed.
+func WithRequestServerURL(enabled bool) HandlerOption {
+	return func(settings *handlerSettings) {
+		settings.requestServerURL = enabled
+	}
+}
+
+// requestSpec returns the `spec` served to the request `r`, which is a copy of the spec with the server url of the request,
+// when it is enabled (see [WithRequestServerURL])
+func (settings *handlerSettings) requestSpec(spec openapi3.T, r *http.Request) openapi3.T {
+	if !settings.requestServerURL {
+		return spec
+	}
+
+	server := openapi3.Server{}
+	if len(spec.Servers) > 0 && spec.Servers[0] != nil {
+		server = *spec.Servers[0]
+	}
+	server.URL = requestServerURL(r, settings.basePath)
+
+	servers := make(openapi3.Servers, 0, max(len(spec.Servers), 1))
+	servers = append(servers, &server)
+	if len(spec.Servers) > 1 {
+		servers = append(servers, spec.Servers[1:]...)
+	}
+	spec.Servers = servers
+	return spec
+}
+
+// requestServerURL returns the url of the handler with the `basePath`, that is reached by the request `r`
+func requestServerURL(r *http.Request, basePath string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := forwardedHeader(r, "X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	host := r.Host
+	if forwarded := forwardedHeader(r, "X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+
+	p := path.Join("/", forwardedHeader(r, "X-Forwarded-Prefix"), basePath)
+	if p == "/" {
+		p = ""
+	}
+	return scheme + "://" + host + p
+}
+
+// forwardedHeader returns the first value of the header `name`, which is the value set by the proxy closest to the client
+func forwardedHeader(r *http.Request, name string) string {
+	value, _, _ := strings.Cut(r.Header.Get(name), ",")
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,74 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestRequestServerURL(t *testing.T) {
+	fns := []Function{
+		Func("/greet", func(ctx context.Context, name string) (string, error) {
+			return "hello " + name, nil
+		}),
+	}
+	defaultSpec := &openapi3.T{
+		OpenAPI: "3.0.0",
+		Servers: openapi3.Servers{{URL: "http://localhost:8080/api", Description: "local"}, {URL: "https://example.com/api"}},
+	}
+	h, err := NewHandler(fns, WithDefaultSpec(defaultSpec), WithPathPrefix("/api"), WithSwaggerUI("/ui"), WithRequestServerURL(true))
+	got.T(t).Must().Nil(err)
+
+	fetch := func(g got.G, header http.Header) openapi3.T {
+		r := httptest.NewRequest(http.MethodGet, "http://api.example.com/api/swagger.json", nil)
+		for name, values := range header {
+			r.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		g.Must().Eq(w.Code, http.StatusOK)
+
+		var spec openapi3.T
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &spec))
+		return spec
+	}
+
+	t.Run("host", func(t *testing.T) {
+		g := got.T(t)
+		spec := fetch(g, nil)
+		g.Eq(spec.Servers[0].URL, "http://api.example.com/api")
+		g.Eq(spec.Servers[0].Description, "local")
+		g.Eq(spec.Servers[1].URL, "https://example.com/api")
+	})
+
+	t.Run("forwarded", func(t *testing.T) {
+		g := got.T(t)
+		spec := fetch(g, http.Header{
+			"X-Forwarded-Proto":  {"https"},
+			"X-Forwarded-Host":   {"public.example.com, internal"},
+			"X-Forwarded-Prefix": {"/v1"},
+		})
+		g.Eq(spec.Servers[0].URL, "https://public.example.com/v1/api")
+	})
+
+	t.Run("shared spec is not changed", func(t *testing.T) {
+		g := got.T(t)
+		fetch(g, nil)
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		g.Eq(spec.Servers[0].URL, "http://localhost:8080/api")
+	})
+}
+
+func TestRequestServerURLWithoutServers(t *testing.T) {
+	g := got.T(t)
+	settings := &handlerSettings{requestServerURL: true}
+	spec := settings.requestSpec(openapi3.T{}, httptest.NewRequest(http.MethodGet, "http://localhost/swagger.json", nil))
+	g.Len(spec.Servers, 1)
+	g.Eq(spec.Servers[0].URL, "http://localhost")
+}
@@ -0,0 +1,92 @@
+package expose
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// encodedSpec is the served spec with its ETag
+type encodedSpec struct {
+	data []byte
+	etag string
+}
+
+// newSpecHandler serves the spec provided by `spec`. The spec is encoded once and can be cached by clients with its ETag.
+// Specs with the server url of the request (see [WithRequestServerURL]) are encoded per request.
+// Without a CORS configuration (see [WithCORS]), the spec may be fetched from all origins, e.g. by documentation sites.
+func newSpecHandler(settings *handlerSettings, spec func() (openapi3.T, error)) http.HandlerFunc {
+	spec = sync.OnceValues(spec)
+	encode := func(spec openapi3.T) (encodedSpec, error) {
+		var buf bytes.Buffer
+		if err := specEncoding.GetEncoder(&buf).Encode(spec); err != nil {
+			return encodedSpec{}, err
+		}
+		return encodedSpec{buf.Bytes(), bodyETag(buf.Bytes())}, nil
+	}
+	encoded := sync.OnceValues(func() (encodedSpec, error) {
+		spec, err := spec()
+		if err != nil {
+			return encodedSpec{}, err
+		}
+		return encode(spec)
+	})
+	encodeRequestSpec := func(r *http.Request) (encodedSpec, error) {
+		spec, err := spec()
+		if err != nil {
+			return encodedSpec{}, err
+		}
+		return encode(settings.requestSpec(spec, r))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var spec encodedSpec
+		var err error
+		if settings.requestServerURL {
+			spec, err = encodeRequestSpec(r)
+		} else {
+			spec, err = encoded()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if settings.cors == nil {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Cache-Control", settings.specCacheControl)
+		w.Header().Set("ETag", spec.etag)
+
+		if etagMatches(r.Header.Get("If-None-Match"), spec.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("content-type", specEncoding.MimeType)
+		w.Write(spec.data)
+	}
+}
+
+// bodyETag derives the quoted entity tag of a response body
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header `ifNoneMatch` matches the `etag`.
+// Weak tags match as well, since the condition only compares the representations.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestSpecHandler(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) { return 1, nil }),
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger.json", nil))
+
+		g.Eq(w.Code, http.StatusOK)
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "*")
+		g.Eq(w.Header().Get("Cache-Control"), "no-cache")
+		g.Has(w.Body.String(), "/counter/get")
+
+		etag := w.Header().Get("ETag")
+		g.Len(etag, 34)
+
+		r := httptest.NewRequest(http.MethodGet, "/swagger.json", nil)
+		r.Header.Set("If-None-Match", `"other", W/`+etag)
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Code, http.StatusNotModified)
+		g.Eq(w.Body.Len(), 0)
+		g.Eq(w.Header().Get("ETag"), etag)
+	})
+
+	t.Run("cors and cache control", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns,
+			WithCORS(CORSConfig{AllowedOrigins: []string{"https://docs.example.com"}}),
+			WithSpecCacheControl("public, max-age=300"))
+		g.Must().Nil(err)
+
+		r := httptest.NewRequest(http.MethodGet, "/swagger.json", nil)
+		r.Header.Set("Origin", "https://docs.example.com")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		g.Eq(w.Header().Get("Access-Control-Allow-Origin"), "https://docs.example.com")
+		g.Eq(w.Header().Get("Cache-Control"), "public, max-age=300")
+	})
+}
@@ -0,0 +1,45 @@
+package expose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SpecJSON returns the canonical JSON of the openapi spec of the functions `fns`, as it is served by the [Handler]
+// created with the same `options` (see [BuildSpec]).
+// The keys of all objects are sorted, the JSON is indented with two spaces and ends with a newline.
+// The output only changes with the spec, so it is suitable for golden tests, that catch accidental changes of the API.
+func SpecJSON(fns []Function, options ...HandlerOption) ([]byte, error) {
+	spec, err := BuildSpec(fns, options...)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalJSON(&spec)
+}
+
+// canonicalJSON encodes `v` with sorted keys and indentation. Extensions and examples of the spec can hold arbitrary values,
+// e.g. structs, so `v` is decoded into plain maps first, which are always encoded with sorted keys.
+func canonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the spec: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// numbers are kept as they are
+	dec.UseNumber()
+	var plain any
+	if err := dec.Decode(&plain); err != nil {
+		return nil, fmt.Errorf("failed to decode the spec: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(plain); err != nil {
+		return nil, fmt.Errorf("failed to encode the spec: %w", err)
+	}
+	return buf.Bytes(), nil
+}
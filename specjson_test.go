@@ -0,0 +1,59 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+func TestSpecJSON(t *testing.T) {
+	type extension struct {
+		Zebra string `json:"zebra"`
+		Alpha string `json:"alpha"`
+	}
+
+	fns := []Function{
+		Func("/greet", func(ctx context.Context, name string) (string, error) {
+			return "hello " + name, nil
+		}, WithExtension("x-owner", extension{Zebra: "z", Alpha: "<a>"})),
+		FuncNullary("/ping", func(ctx context.Context) (string, error) {
+			return "pong", nil
+		}),
+	}
+	opts := []HandlerOption{WithErrorCodeStatus(map[string]int{"not_found": 404, "gone": 404, "conflict": 409})}
+
+	g := got.T(t)
+	data, err := SpecJSON(fns, opts...)
+	g.Must().Nil(err)
+
+	t.Run("stable", func(t *testing.T) {
+		g := got.T(t)
+		for i := 0; i < 10; i++ {
+			again, err := SpecJSON(fns, opts...)
+			g.Must().Nil(err)
+			g.Eq(string(again), string(data))
+		}
+	})
+
+	t.Run("canonical", func(t *testing.T) {
+		g := got.T(t)
+		g.True(strings.HasSuffix(string(data), "}\n"))
+		// the keys of extension values are sorted and html is not escaped
+		g.Has(string(data), "\"x-owner\": {\n          \"alpha\": \"<a>\",\n          \"zebra\": \"z\"\n        }")
+	})
+
+	t.Run("matches the spec", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := BuildSpec(fns, opts...)
+		g.Must().Nil(err)
+
+		var decoded openapi3.T
+		g.Must().Nil(json.Unmarshal(data, &decoded))
+		g.Eq(decoded.Paths.Len(), spec.Paths.Len())
+		g.Eq(decoded.Paths.Find("/greet").Post.Responses.Status(404).Value.Description, spec.Paths.Find("/greet").Post.Responses.Status(404).Value.Description)
+	})
+}
@@ -0,0 +1,61 @@
+package expose
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/flowchartsman/swaggerui"
+)
+
+// SwaggerUISpec is a spec, that is offered by the swagger UI, see [NewSwaggerUIHandlerForURLs]
+type SwaggerUISpec struct {
+	// Name is shown in the spec dropdown
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// NewSwaggerUIHandlerForURL serves the swagger UI for the spec, that is served at `specURL`, e.g. the swagger.json endpoint
+// of a [Handler]. Unlike [NewSwaggerUIHandler], it does not reflect the spec itself, so the UI always shows the served spec.
+func NewSwaggerUIHandlerForURL(specURL string) *SwaggerUIHandler {
+	return newSwaggerUIHandlerForConfig(map[string]any{"url": specURL})
+}
+
+// NewSwaggerUIHandlerForURLs serves the swagger UI for multiple served specs, e.g. the versions of an API (see [Group]).
+// The UI offers the specs in a dropdown and shows the first spec by default.
+func NewSwaggerUIHandlerForURLs(specs ...SwaggerUISpec) *SwaggerUIHandler {
+	return newSwaggerUIHandlerForConfig(map[string]any{"urls": specs, "layout": "StandaloneLayout"})
+}
+
+// newSwaggerUIHandlerForConfig serves the swagger UI, that is initialized with the swagger UI configuration `config`.
+func newSwaggerUIHandlerForConfig(config map[string]any) *SwaggerUIHandler {
+	data, _ := json.Marshal(config)
+	initializer := []byte(fmt.Sprintf(swaggerUIInitializer, data))
+
+	// the embedded assets are served as they are, only the spec is not used
+	assets := swaggerui.Handler(nil)
+
+	return &SwaggerUIHandler{
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/swagger-initializer.js" {
+				assets.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("content-type", "text/javascript; charset=utf-8")
+			w.Write(initializer)
+		}),
+	}
+}
+
+// swaggerUIInitializer replaces the initializer of the embedded swagger UI, which loads the embedded spec
+const swaggerUIInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle(Object.assign(%s, {
+    dom_id: '#swagger-ui',
+    deepLinking: true,
+    presets: [
+      SwaggerUIBundle.presets.apis,
+      SwaggerUIStandalonePreset
+    ]
+  }));
+};
+`
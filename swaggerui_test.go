@@ -0,0 +1,42 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestSwaggerUIForURL(t *testing.T) {
+	fns := []Function{
+		FuncNullary("/counter/get", func(ctx context.Context) (int, error) { return 1, nil }),
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		g := got.T(t)
+		ui := NewSwaggerUIHandlerForURL("/api/spec.json")
+
+		w := httptest.NewRecorder()
+		ui.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/swagger-initializer.js", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Has(w.Body.String(), `{"url":"/api/spec.json"}`)
+
+		w = httptest.NewRecorder()
+		ui.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Has(w.Body.String(), "swagger-initializer.js")
+	})
+
+	t.Run("loads the served spec", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithPathPrefix("/api"), WithSwaggerJSONPath("/openapi.json"), WithSwaggerUI("/swagger-ui"))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/swagger-ui/swagger-initializer.js", nil))
+		g.Eq(w.Code, http.StatusOK)
+		g.Has(w.Body.String(), `{"url":"/api/openapi.json"}`)
+	})
+}
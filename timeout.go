@@ -0,0 +1,81 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrTimeout is wrapped by the errors of calls, that exceeded their timeout (see [WithTimeout] and [WithHeaderTimeout]).
+// The handler responds with 504 Gateway Timeout.
+var ErrTimeout = errors.New("timeout exceeded")
+
+// StatusClientClosedRequest is the (non-standard) status of the responses to requests, that the client canceled, see [ErrCanceled]
+const StatusClientClosedRequest = 499
+
+// ErrCanceled is wrapped by the errors of calls, that failed because the client canceled the request, e.g. by disconnecting.
+// The handler responds with 499 Client Closed Request (see [StatusClientClosedRequest]) and the call is not treated as a failure
+// of the function: it is logged with [OutcomeCanceled], does not fail the trace and does not count as failure of a [CircuitBreaker].
+var ErrCanceled = errors.New("request canceled")
+
+// withTimeout derives the deadline of the call from the timeout header (see [WithHeaderTimeout]) or the timeout of the handler.
+// Header timeouts, that are not positive integers or exceed the range of durations, are ignored.
+func (settings *handlerSettings) withTimeout(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	timeout := settings.timeout
+	clientTimeout := false
+	if settings.timeoutHeader != "" {
+		// the milliseconds are compared before they are converted, since the conversion of large values overflows
+		if ms, err := strconv.ParseInt(r.Header.Get(settings.timeoutHeader), 10, 64); err == nil && ms > 0 {
+			switch {
+			case settings.maxHeaderTimeout > 0 && ms > settings.maxHeaderTimeout.Milliseconds():
+				timeout = settings.maxHeaderTimeout
+			case ms <= math.MaxInt64/int64(time.Millisecond):
+				timeout = time.Duration(ms) * time.Millisecond
+				clientTimeout = true
+			}
+		}
+	}
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	if clientTimeout {
+		deadline, _ := ctx.Deadline()
+		ctx = context.WithValue(ctx, clientDeadlineKey{}, deadline)
+	}
+	return ctx, cancel
+}
+
+// clientDeadlineKey is the key of the deadline, that the client set with the timeout header, see [WithHeaderTimeout]
+type clientDeadlineKey struct{}
+
+// clientDeadlineExceeded reports whether the call with the context `ctx` exceeded the deadline, that the client set with the timeout header
+func clientDeadlineExceeded(ctx context.Context) bool {
+	clientDeadline, ok := ctx.Value(clientDeadlineKey{}).(time.Time)
+	if !ok || ctx.Err() != context.DeadlineExceeded {
+		return false
+	}
+	deadline, _ := ctx.Deadline()
+	return deadline.Equal(clientDeadline)
+}
+
+// contextError marks the error `err` of a call, which exceeded its deadline, as [ErrTimeout]
+// and the error of a call, that was canceled, as [ErrCanceled].
+// The context `ctx` of the call classifies errors, that do not wrap the error of the context.
+func contextError(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded):
+		return SetErrStatus(fmt.Errorf("%w: %w", ErrTimeout, err), http.StatusGatewayTimeout)
+	case ctx.Err() == context.Canceled || errors.Is(err, context.Canceled):
+		return SetErrStatus(fmt.Errorf("%w: %w", ErrCanceled, err), StatusClientClosedRequest)
+	default:
+		return err
+	}
+}
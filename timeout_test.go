@@ -0,0 +1,151 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestHeaderTimeout(t *testing.T) {
+	var deadline time.Duration
+	fns := []Function{
+		FuncNullary("/work", func(ctx context.Context) (string, error) {
+			d, ok := ctx.Deadline()
+			if !ok {
+				deadline = 0
+				return "done", nil
+			}
+			deadline = time.Until(d)
+			if deadline > 50*time.Millisecond {
+				return "done", nil
+			}
+			<-ctx.Done()
+			return "", ctx.Err()
+		}),
+	}
+	h, err := NewHandler(fns, WithTimeout(time.Minute), WithHeaderTimeout("X-Timeout-Ms", 10*time.Second))
+	got.T(t).Must().Nil(err)
+
+	call := func(timeout string) int {
+		r := httptest.NewRequest(http.MethodPost, "/work", nil)
+		if timeout != "" {
+			r.Header.Set("X-Timeout-Ms", timeout)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call("10"), http.StatusGatewayTimeout)
+		g.Lte(deadline, 10*time.Millisecond)
+	})
+
+	t.Run("clamped", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call("3600000"), http.StatusOK)
+		g.Lte(deadline, 10*time.Second)
+		g.Gt(deadline, 9*time.Second)
+	})
+
+	t.Run("overflowing", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call("100000000000000000"), http.StatusOK)
+		g.Gt(deadline, 9*time.Second)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		g := got.T(t)
+		g.Eq(call("soon"), http.StatusOK)
+		g.Gt(deadline, 59*time.Second)
+
+		g.Eq(call(""), http.StatusOK)
+		g.Gt(deadline, 59*time.Second)
+	})
+}
+
+func TestCanceled(t *testing.T) {
+	var entries []LogEntry
+	cb := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 1})
+	fns := []Function{
+		FuncNullary("/wait", func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, WithCircuitBreaker(cb)),
+		FuncNullary("/downstream", func(ctx context.Context) (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+			defer cancel()
+			<-ctx.Done()
+			return "", ctx.Err()
+		}),
+	}
+	h, err := NewHandler(fns, WithLogger(func(ctx context.Context, entry LogEntry) {
+		entries = append(entries, entry)
+	}))
+	got.T(t).Must().Nil(err)
+
+	t.Run("client closed request", func(t *testing.T) {
+		g := got.T(t)
+		entries = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/wait", nil).WithContext(ctx))
+
+		g.Eq(w.Code, StatusClientClosedRequest)
+		g.Must().Len(entries, 1)
+		g.Is(entries[0].Err, ErrCanceled)
+		g.Eq(entries[0].Outcome, OutcomeCanceled)
+		g.Eq(entries[0].Outcome.String(), "canceled")
+		// the canceled call is not a failure of the function
+		g.Eq(cb.State(), CircuitClosed)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		g := got.T(t)
+		entries = nil
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/downstream", nil))
+
+		g.Eq(w.Code, http.StatusGatewayTimeout)
+		g.Must().Len(entries, 1)
+		g.Is(entries[0].Err, ErrTimeout)
+		g.Eq(entries[0].Outcome, OutcomeTimeout)
+	})
+}
+
+func TestHeaderTimeoutCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerOptions{FailureThreshold: 2})
+	fns := []Function{
+		FuncNullary("/wait", func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}, WithCircuitBreaker(cb)),
+	}
+	h, err := NewHandler(fns, WithHeaderTimeout("X-Timeout-Ms", 5*time.Millisecond))
+	got.T(t).Must().Nil(err)
+
+	call := func(timeout string) int {
+		r := httptest.NewRequest(http.MethodPost, "/wait", nil)
+		r.Header.Set("X-Timeout-Ms", timeout)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	g := got.T(t)
+	// the client's deadline is not a failure of the function
+	g.Eq(call("1"), http.StatusGatewayTimeout)
+	g.Eq(call("1"), http.StatusGatewayTimeout)
+	g.Eq(cb.State(), CircuitClosed)
+	g.Eq(cb.Failures(), 0)
+
+	// the clamped deadline is the handler's
+	g.Eq(call("1000"), http.StatusGatewayTimeout)
+	g.Eq(cb.Failures(), 1)
+}
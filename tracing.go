@@ -0,0 +1,69 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// startSpan starts the server span of a call to `fn`, when tracing is enabled (see [WithTracing]).
+// The span is a child of the span context propagated by the request headers.
+func (settings *handlerSettings) startSpan(r *http.Request, fn Function) (context.Context, trace.Span) {
+	if settings.tracer == nil {
+		return r.Context(), noop.Span{}
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	return settings.tracer.Start(ctx, settings.operationID(fn),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("expose.module", fn.Module()),
+			attribute.String("expose.function", fn.Name()),
+			attribute.String("expose.path", fn.Path()),
+		))
+}
+
+// startPhase starts a child span for a phase (decode, apply, encode) of the call
+func (settings *handlerSettings) startPhase(ctx context.Context, phase string) (context.Context, trace.Span) {
+	if settings.tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return settings.tracer.Start(ctx, phase)
+}
+
+// traceDecoder records the decoding of the request in a "decode" span
+func (settings *handlerSettings) traceDecoder(ctx context.Context, dec Decoder) Decoder {
+	if settings.tracer == nil {
+		return dec
+	}
+	return DecoderFunc(func(v any) error {
+		_, span := settings.tracer.Start(ctx, "decode")
+		defer span.End()
+
+		err := dec.Decode(v)
+		failSpan(span, err)
+		return err
+	})
+}
+
+// failSpan marks the `span` as failed, when `err` is not nil.
+// Canceled requests (see [ErrCanceled]) are only flagged, since the call itself did not fail.
+func failSpan(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	if errors.Is(err, ErrCanceled) {
+		span.SetAttributes(attribute.Bool("expose.canceled", true))
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
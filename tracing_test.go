@@ -0,0 +1,75 @@
+package expose
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	prevPropagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prevPropagator) })
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	h, err := NewHandler([]Function{
+		Func("/counter/inc", func(ctx context.Context, delta int) (int, error) {
+			if delta < 0 {
+				return 0, errors.New("negative delta")
+			}
+			return delta, nil
+		}),
+	}, WithTracing(tracer))
+	got.T(t).Must().Nil(err)
+
+	spansByName := func() map[string]sdktrace.ReadOnlySpan {
+		spans := map[string]sdktrace.ReadOnlySpan{}
+		for _, s := range recorder.Ended() {
+			spans[s.Name()] = s
+		}
+		return spans
+	}
+
+	t.Run("success", func(t *testing.T) {
+		g := got.T(t)
+
+		req := httptest.NewRequest(http.MethodPost, "/counter/inc", strings.NewReader("1"))
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := spansByName()
+		g.Len(recorder.Ended(), 4)
+
+		root := spans["counter#inc"]
+		g.Eq(root.SpanContext().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+		g.Eq(root.Parent().SpanID().String(), "00f067aa0ba902b7")
+		g.Eq(root.Status().Code, codes.Unset)
+
+		g.Eq(spans["apply"].Parent().SpanID(), root.SpanContext().SpanID())
+		g.Eq(spans["decode"].Parent().SpanID(), spans["apply"].SpanContext().SpanID())
+		g.Eq(spans["encode"].Parent().SpanID(), root.SpanContext().SpanID())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		g := got.T(t)
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counter/inc", strings.NewReader("-1")))
+
+		root := recorder.Ended()[len(recorder.Ended())-1]
+		g.Eq(root.Name(), "counter#inc")
+		g.Eq(root.Status().Code, codes.Error)
+		g.Eq(root.Status().Description, "negative delta")
+	})
+}
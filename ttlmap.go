@@ -0,0 +1,90 @@
+package expose
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// defaultMemoryStoreCapacity is the default capacity of the memory stores, see [MemoryCacheStore.WithCapacity] and [MemoryIdempotencyStore.WithCapacity]
+const defaultMemoryStoreCapacity = 10_000
+
+// ttlMap is a map, whose entries expire. It backs the memory stores of the cache and the idempotency.
+// It holds at most `capacity` entries and evicts the least recently used entry, when a new entry exceeds the capacity.
+// Expired entries are removed, when they are read or when they reach the end of the LRU list, so every operation takes constant time.
+type ttlMap[K comparable, V any] struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	capacity int
+	entries  map[K]*list.Element
+	// lru orders the entries from the most to the least recently used
+	lru *list.List
+}
+
+type ttlEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+func newTTLMap[K comparable, V any](capacity int) *ttlMap[K, V] {
+	return &ttlMap[K, V]{now: time.Now, capacity: capacity, entries: map[K]*list.Element{}, lru: list.New()}
+}
+
+// get returns the value of `key`, unless it is missing or expired
+func (m *ttlMap[K, V]) get(key K) (V, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var zero V
+	el, ok := m.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*ttlEntry[K, V])
+	if !m.now().Before(entry.expires) {
+		m.remove(el)
+		return zero, false
+	}
+	m.lru.MoveToFront(el)
+	return entry.value, true
+}
+
+// set stores `value` under `key` for `ttl`
+func (m *ttlMap[K, V]) set(key K, value V, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*ttlEntry[K, V])
+		entry.value, entry.expires = value, now.Add(ttl)
+		m.lru.MoveToFront(el)
+	} else {
+		m.entries[key] = m.lru.PushFront(&ttlEntry[K, V]{key, value, now.Add(ttl)})
+	}
+
+	for el := m.lru.Back(); el != nil && !now.Before(el.Value.(*ttlEntry[K, V]).expires); el = m.lru.Back() {
+		m.remove(el)
+	}
+	for m.capacity > 0 && m.lru.Len() > m.capacity {
+		m.remove(m.lru.Back())
+	}
+}
+
+// setCapacity limits the number of entries to `capacity`. A capacity of 0 or less is unlimited.
+func (m *ttlMap[K, V]) setCapacity(capacity int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.capacity = capacity
+	for m.capacity > 0 && m.lru.Len() > m.capacity {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *ttlMap[K, V]) remove(el *list.Element) {
+	m.lru.Remove(el)
+	delete(m.entries, el.Value.(*ttlEntry[K, V]).key)
+}
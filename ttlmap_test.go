@@ -0,0 +1,58 @@
+package expose
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ysmood/got"
+)
+
+func TestTTLMap(t *testing.T) {
+	t.Run("expiry", func(t *testing.T) {
+		g := got.T(t)
+		now := time.Now()
+		m := newTTLMap[string, int](0)
+		m.now = func() time.Time { return now }
+
+		m.set("a", 1, time.Minute)
+		m.set("b", 2, time.Hour)
+		v, ok := m.get("a")
+		g.True(ok)
+		g.Eq(v, 1)
+
+		now = now.Add(time.Minute)
+		_, ok = m.get("a")
+		g.False(ok)
+		g.Len(m.entries, 1)
+
+		// expired entries at the end of the LRU list are removed on writes
+		m.set("c", 3, time.Minute)
+		now = now.Add(time.Hour)
+		m.set("d", 4, time.Minute)
+		g.Len(m.entries, 1)
+	})
+
+	t.Run("capacity", func(t *testing.T) {
+		g := got.T(t)
+		m := newTTLMap[string, int](2)
+
+		m.set("a", 1, time.Minute)
+		m.set("b", 2, time.Minute)
+		// reading "a" makes "b" the least recently used entry
+		m.get("a")
+		m.set("c", 3, time.Minute)
+
+		_, ok := m.get("b")
+		g.False(ok)
+		_, ok = m.get("a")
+		g.True(ok)
+		_, ok = m.get("c")
+		g.True(ok)
+		g.Eq(m.lru.Len(), 2)
+
+		m.setCapacity(1)
+		g.Len(m.entries, 1)
+		_, ok = m.get("c")
+		g.True(ok)
+	})
+}
@@ -0,0 +1,62 @@
+package expose
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// UnionVariant is implemented by the variants of a [Union], whose discriminator value differs from their schema identifier
+type UnionVariant interface {
+	// DiscriminatorValue is the value of the discriminator property, that identifies the variant
+	DiscriminatorValue() string
+}
+
+// UnionSchema describes a value, that is one of multiple types. See [Union].
+type UnionSchema struct {
+	propertyName string
+	types        []any
+}
+
+// Union describes a value, that is one of the `types`, as a `oneOf` schema, whose variants are told apart by the
+// discriminator property `propertyName`. Use it in the [SchemaProvider] of a type, that holds an interface value, e.g.
+//
+//	func (Shape) JSONSchema(gen *openapi3gen.Generator, schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+//		return expose.Union("kind", Circle{}, Square{}).JSONSchema(gen, schemas)
+//	}
+//
+// The variants are moved to the components/schemas. Their discriminator value is their schema identifier,
+// unless they implement [UnionVariant]. Decoding the variants is up to the type, e.g. with a custom [json.Unmarshaler].
+func Union(propertyName string, types ...any) UnionSchema {
+	return UnionSchema{propertyName: propertyName, types: types}
+}
+
+func (u UnionSchema) JSONSchema(gen *openapi3gen.Generator, schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+	union := openapi3.NewOneOfSchema()
+	union.Discriminator = &openapi3.Discriminator{PropertyName: u.propertyName}
+
+	for _, t := range u.types {
+		ref, err := gen.NewSchemaRefForValue(t, openapi3.Schemas{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the schema of the union variant %T: %w", t, err)
+		}
+		union.OneOf = append(union.OneOf, ref)
+
+		variant, ok := t.(UnionVariant)
+		if !ok {
+			continue
+		}
+		id, ok := ref.Value.Extensions["$id"].(string)
+		if !ok {
+			return nil, fmt.Errorf("the union variant %T is not a struct", t)
+		}
+		if union.Discriminator.Mapping == nil {
+			union.Discriminator.Mapping = map[string]string{}
+		}
+		union.Discriminator.Mapping[variant.DiscriminatorValue()] = "#/components/schemas/" + strings.TrimPrefix(id, "#")
+	}
+
+	return openapi3.NewSchemaRef("", union), nil
+}
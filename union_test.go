@@ -0,0 +1,113 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/ysmood/got"
+)
+
+type circle struct {
+	Kind   string  `json:"kind"`
+	Radius float64 `json:"radius" validate:"min=0"`
+}
+
+func (circle) DiscriminatorValue() string { return "circle" }
+
+type square struct {
+	Kind string  `json:"kind"`
+	Side float64 `json:"side"`
+}
+
+// shape holds a circle or a square
+type shape struct {
+	Value any
+}
+
+func (shape) JSONSchema(gen *openapi3gen.Generator, schemas openapi3.Schemas) (*openapi3.SchemaRef, error) {
+	return Union("kind", circle{}, square{}).JSONSchema(gen, schemas)
+}
+
+func (s shape) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Value)
+}
+
+func (s *shape) UnmarshalJSON(data []byte) error {
+	var kind struct{ Kind string }
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return err
+	}
+	switch kind.Kind {
+	case "circle":
+		var c circle
+		s.Value = &c
+	case "square":
+		var sq square
+		s.Value = &sq
+	default:
+		return fmt.Errorf("unknown shape %s", kind.Kind)
+	}
+	return json.Unmarshal(data, s.Value)
+}
+
+type drawing struct {
+	Main   shape   `json:"main"`
+	Shapes []shape `json:"shapes"`
+}
+
+func TestUnion(t *testing.T) {
+	g := got.T(t)
+
+	spec, err := ReflectSpec(openapi3.T{}, []Function{
+		Func("/drawings/create", func(ctx context.Context, d drawing) (int, error) {
+			return len(d.Shapes), nil
+		}),
+	}, WithSchemaIdentifier(ShortSchemaIdentifier))
+	g.Must().Nil(err)
+
+	drawingSchema := spec.Components.Schemas["expose.drawing"].Value
+	main := drawingSchema.Properties["main"].Value
+	g.Eq(main.Discriminator.PropertyName, "kind")
+	g.Eq(main.Discriminator.Mapping, map[string]string{"circle": "#/components/schemas/expose.circle"})
+	g.Len(main.OneOf, 2)
+	g.Eq(main.OneOf[0].Ref, "#/components/schemas/expose.circle")
+	g.Eq(main.OneOf[1].Ref, "#/components/schemas/expose.square")
+
+	// the variants are extracted into the components
+	g.Eq(spec.Components.Schemas["expose.circle"].Value.Properties["radius"].Value.Type.Is("number"), true)
+	g.Eq(spec.Components.Schemas["expose.square"].Value.Properties["side"].Value.Type.Is("number"), true)
+	g.Eq(drawingSchema.Properties["shapes"].Value.Items.Value.OneOf[1].Ref, "#/components/schemas/expose.square")
+
+	data, err := json.Marshal(spec)
+	g.Must().Nil(err)
+	_, err = openapi3.NewLoader().LoadFromData(data)
+	g.Must().Nil(err)
+
+	t.Run("validation", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler([]Function{
+			Func("/drawings/create", func(ctx context.Context, d drawing) (int, error) {
+				return len(d.Shapes), nil
+			}, Validate(true)),
+		})
+		g.Must().Nil(err)
+
+		create := func(body string) int {
+			r := httptest.NewRequest(http.MethodPost, "/drawings/create", strings.NewReader(body))
+			r.Header.Set("content-type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			return w.Code
+		}
+
+		g.Eq(create(`{"main":{"kind":"circle","radius":1},"shapes":[{"kind":"circle","radius":2}]}`), http.StatusOK)
+		g.Eq(create(`{"main":{"kind":"circle","radius":-1},"shapes":[]}`), http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,51 @@
+package expose
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validateJSON validates `v` against the schema `ref`. When `ref` points to the components/schemas of `spec`, the component is used.
+// `v` is converted to its JSON representation first, since [openapi3.Schema.VisitJSON] only handles JSON values.
+// The fields are named by the `namer` of the handler, like on the wire.
+func validateJSON(spec openapi3.T, ref *openapi3.SchemaRef, v any, namer *fieldNamer) error {
+	schema := ref
+	if ref.Ref != "" {
+		id := strings.TrimPrefix(ref.Ref, "#/components/schemas/")
+		var ok bool
+		if schema, ok = spec.Components.Schemas[id]; !ok {
+			return fmt.Errorf("schema '%s' not found", id)
+		}
+	}
+	if schema.Value == nil {
+		return fmt.Errorf("schema '%s' not found", ref.Ref)
+	}
+
+	data, err := namer.marshal(v)
+	if err != nil {
+		return err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	return schema.Value.VisitJSON(value, openapi3.EnableFormatValidation())
+}
+
+// validateResponse validates the result `res` of `fn` against its response schema in `spec`
+func validateResponse(spec openapi3.T, fn Function, res any, namer *fieldNamer) error {
+	content := operation(spec, fn).Responses.Status(200).Value.Content.Get("application/json")
+	if content == nil {
+		// e.g. binary results
+		return nil
+	}
+	ref := content.Schema
+	if err := validateJSON(spec, ref, res, namer); err != nil {
+		return fmt.Errorf("invalid response of %s: %w", fn.Path(), err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ysmood/got"
+)
+
+func TestRequestValidation(t *testing.T) {
+	g := got.T(t)
+
+	type account struct {
+		Name   string
+		Status status
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/accounts/save", func(ctx context.Context, a account) error {
+			return nil
+		}, Validate(true)),
+	}, WithReflection(RegisterIntEnum(map[status]string{statusActive: "Active", statusBlocked: "Blocked"})))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/accounts/save", strings.NewReader(`{"Name":"acme","Status":1}`)))
+	g.Eq(w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/accounts/save", strings.NewReader(`{"Name":"acme","Status":3}`)))
+	g.Eq(w.Code, http.StatusBadRequest)
+}
+
+func TestResponseValidation(t *testing.T) {
+	type page struct {
+		Items []string
+	}
+
+	fns := []Function{
+		FuncNullary("/items/list", func(ctx context.Context) (page, error) {
+			// nil slices are encoded as null, which does not match the array schema
+			return page{}, nil
+		}),
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns)
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/list", nil))
+		g.Eq(w.Code, http.StatusOK)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithResponseValidation(true))
+		g.Must().Nil(err)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/list", nil))
+		g.Eq(w.Code, http.StatusInternalServerError)
+
+		var body map[string]any
+		g.Must().Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		g.Has(body["message"], "invalid response of /items/list")
+	})
+}
+
+func TestListValidation(t *testing.T) {
+	g := got.T(t)
+
+	type item struct {
+		Name   string
+		Status status
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/items/save", func(ctx context.Context, items []item) error {
+			return nil
+		}, Validate(true)),
+	}, WithReflection(RegisterIntEnum(map[status]string{statusActive: "Active", statusBlocked: "Blocked"})))
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/save", strings.NewReader(`[{"Name":"a","Status":1}]`)))
+	g.Eq(w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/items/save", strings.NewReader(`[{"Name":"a","Status":3}]`)))
+	g.Eq(w.Code, http.StatusBadRequest)
+}
+
+func TestNullableValidation(t *testing.T) {
+	g := got.T(t)
+
+	type address struct{ Street string }
+	type person struct {
+		Name    *string
+		Address *address
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/people/save", func(ctx context.Context, p person) error {
+			return nil
+		}, Validate(true)),
+	})
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people/save", strings.NewReader(`{"Name":null,"Address":null}`)))
+	g.Eq(w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/people/save", strings.NewReader(`{"Address":{"Street":"Main St"}}`)))
+	g.Eq(w.Code, http.StatusOK)
+}
+
+func TestFormatValidation(t *testing.T) {
+	g := got.T(t)
+
+	type appointment struct {
+		Day string `format:"date"`
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/appointments/save", func(ctx context.Context, a appointment) error {
+			return nil
+		}, Validate(true)),
+	})
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/appointments/save", strings.NewReader(`{"Day":"2024-05-01"}`)))
+	g.Eq(w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/appointments/save", strings.NewReader(`{"Day":"tomorrow"}`)))
+	g.Eq(w.Code, http.StatusBadRequest)
+}
+
+func TestConstraintValidation(t *testing.T) {
+	g := got.T(t)
+
+	type order struct {
+		Quantity int    `validate:"min=1,max=100"`
+		Coupon   string `validate:"maxLength=8"`
+	}
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/orders/place", func(ctx context.Context, o order) error {
+			return nil
+		}, Validate(true)),
+	})
+	g.Must().Nil(err)
+
+	for body, status := range map[string]int{
+		`{"Quantity":1,"Coupon":"SPRING"}`:     http.StatusOK,
+		`{"Quantity":0,"Coupon":"SPRING"}`:     http.StatusBadRequest,
+		`{"Quantity":101,"Coupon":"SPRING"}`:   http.StatusBadRequest,
+		`{"Quantity":1,"Coupon":"SPRING2024"}`: http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders/place", strings.NewReader(body)))
+		g.Desc(body).Eq(w.Code, status)
+	}
+}
+
+func TestRecursiveValidation(t *testing.T) {
+	g := got.T(t)
+
+	h, err := NewHandler([]Function{
+		FuncVoid("/nodes/save", func(ctx context.Context, n node) error {
+			return nil
+		}, Validate(true)),
+	})
+	g.Must().Nil(err)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/nodes/save", strings.NewReader(`{"Name":"root","Children":[{"Name":"leaf","Children":[]}]}`)))
+	g.Eq(w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/nodes/save", strings.NewReader(`{"Name":"root","Children":[{"Name":1,"Children":[]}]}`)))
+	g.Eq(w.Code, http.StatusBadRequest)
+}
@@ -0,0 +1,258 @@
+package expose
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SeparateRequestResponseSchemas reflects distinct request and response variants of the schemas, that are used in both roles
+// and differ between them, since they have readOnly or writeOnly properties (fields tagged with `openapi:"readOnly"` or
+// `openapi:"writeOnly"`), directly or in their sub schemas.
+// The variants are identified by the id of the type with the suffix 'Request' or 'Response', e.g. 'github.com.acme.userRequest',
+// and replace the shared schema in the operations. The request variant omits the readOnly properties, the response variant the
+// writeOnly properties, so that neither is required, where it is not sent.
+//
+// Schemas, that are only used in one role, keep their id and their properties, but refer to the variants of their role.
+// ReadOnly query parameters are omitted as well. Webhooks (see [WithWebhook]) use the shared schemas.
+func SeparateRequestResponseSchemas(separate ...bool) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.schemaVariants = len(separate) == 0 || separate[0]
+	}
+}
+
+// schemaRole is the role of a schema in an operation
+type schemaRole int
+
+const (
+	roleRequest schemaRole = iota
+	roleResponse
+)
+
+// suffix is appended to the id of the schema variant of the role
+func (role schemaRole) suffix() string {
+	if role == roleRequest {
+		return "Request"
+	}
+	return "Response"
+}
+
+// omits reports whether the variant of the `role` omits the property `s`
+func (role schemaRole) omits(s *openapi3.Schema) bool {
+	if role == roleRequest {
+		return s.ReadOnly
+	}
+	return s.WriteOnly
+}
+
+// separateSchemaVariants replaces the schemas of the operations in `root`, that differ in requests and responses,
+// with their variants, see [SeparateRequestResponseSchemas]
+func separateSchemaVariants(root *openapi3.T, schemas openapi3.Schemas) {
+	for _, item := range root.Paths.Map() {
+		for _, op := range item.Operations() {
+			op.Parameters = slices.DeleteFunc(op.Parameters, func(param *openapi3.ParameterRef) bool {
+				return param.Value != nil && param.Value.In == openapi3.ParameterInQuery &&
+					param.Value.Schema != nil && param.Value.Schema.Value != nil && param.Value.Schema.Value.ReadOnly
+			})
+		}
+	}
+
+	used := map[schemaRole]map[string]bool{roleRequest: {}, roleResponse: {}}
+	visitOperationSchemas(root, func(role schemaRole, ref *openapi3.SchemaRef) {
+		collectSchemaIDs(ref, schemas, used[role])
+	})
+
+	accessModes := map[string]bool{}
+	separated := map[string]bool{}
+	for id := range used[roleRequest] {
+		if used[roleResponse][id] && hasAccessModes(openapi3.NewSchemaRef("#/components/schemas/"+id, nil), schemas, accessModes, map[string]bool{}) {
+			separated[id] = true
+		}
+	}
+	if len(separated) == 0 {
+		return
+	}
+
+	variants := openapi3.Schemas{}
+	for _, role := range []schemaRole{roleRequest, roleResponse} {
+		for id := range used[role] {
+			if separated[id] {
+				variant := schemaVariant(schemas[id], role, separated, true)
+				if _, ok := variant.Value.Extensions["$id"]; ok {
+					variant.Value.Extensions["$id"] = "#" + id + role.suffix()
+				}
+				variants[id+role.suffix()] = variant
+			} else if !used[1-role][id] {
+				// schemas of a single role refer to the variants of the role
+				schemas[id] = schemaVariant(schemas[id], role, separated, false)
+			}
+		}
+	}
+	for id := range separated {
+		delete(schemas, id)
+	}
+	for id, variant := range variants {
+		schemas[id] = variant
+	}
+
+	visitOperationSchemas(root, func(role schemaRole, ref *openapi3.SchemaRef) {
+		*ref = *schemaVariant(ref, role, separated, true)
+	})
+}
+
+// visitOperationSchemas visits the schemas of the request bodies, query parameters and responses of the operations in `root`
+func visitOperationSchemas(root *openapi3.T, visit func(role schemaRole, ref *openapi3.SchemaRef)) {
+	visitContent := func(role schemaRole, content openapi3.Content) {
+		for _, mediaType := range content {
+			if mediaType.Schema != nil {
+				visit(role, mediaType.Schema)
+			}
+		}
+	}
+
+	for _, item := range root.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				visitContent(roleRequest, op.RequestBody.Value.Content)
+			}
+			for _, param := range op.Parameters {
+				if param.Value != nil && param.Value.In == openapi3.ParameterInQuery && param.Value.Schema != nil {
+					visit(roleRequest, param.Value.Schema)
+				}
+			}
+			for _, res := range op.Responses.Map() {
+				if res.Value != nil {
+					visitContent(roleResponse, res.Value.Content)
+				}
+			}
+		}
+	}
+}
+
+// collectSchemaIDs adds the ids of the components, that `ref` refers to, directly or in its sub schemas, to `ids`
+func collectSchemaIDs(ref *openapi3.SchemaRef, schemas openapi3.Schemas, ids map[string]bool) {
+	if ref.Ref != "" {
+		id := strings.TrimPrefix(ref.Ref, "#/components/schemas/")
+		if ids[id] {
+			return
+		}
+		ids[id] = true
+		if component, ok := schemas[id]; ok {
+			collectSchemaIDs(component, schemas, ids)
+		}
+		return
+	}
+	if ref.Value == nil {
+		return
+	}
+	for _, sub := range subSchemas(ref.Value) {
+		collectSchemaIDs(sub, schemas, ids)
+	}
+}
+
+// hasAccessModes reports whether `ref` has readOnly or writeOnly properties, directly or in its sub schemas.
+// The results of the components are memoized in `memo`, `path` holds the components, that are currently checked.
+func hasAccessModes(ref *openapi3.SchemaRef, schemas openapi3.Schemas, memo, path map[string]bool) bool {
+	if ref.Ref != "" {
+		id := strings.TrimPrefix(ref.Ref, "#/components/schemas/")
+		if result, ok := memo[id]; ok {
+			return result
+		}
+		component, ok := schemas[id]
+		if !ok || path[id] {
+			return false
+		}
+		path[id] = true
+		defer delete(path, id)
+		memo[id] = hasAccessModes(component, schemas, memo, path)
+		return memo[id]
+	}
+	if ref.Value == nil {
+		return false
+	}
+	for _, prop := range ref.Value.Properties {
+		if prop.Value != nil && (prop.Value.ReadOnly || prop.Value.WriteOnly) {
+			return true
+		}
+	}
+	for _, sub := range subSchemas(ref.Value) {
+		if hasAccessModes(sub, schemas, memo, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaVariant copies `ref` for the `role`: the $refs to the `separated` components are replaced with $refs to their variants
+// and, with `omit`, the properties, that the role omits, are removed. $refs to other components are kept as they are.
+func schemaVariant(ref *openapi3.SchemaRef, role schemaRole, separated map[string]bool, omit bool) *openapi3.SchemaRef {
+	if ref.Ref != "" {
+		if id := strings.TrimPrefix(ref.Ref, "#/components/schemas/"); separated[id] {
+			return openapi3.NewSchemaRef("#/components/schemas/"+id+role.suffix(), nil)
+		}
+		return ref
+	}
+	if ref.Value == nil {
+		return ref
+	}
+
+	variant := *ref.Value
+	copyRefs := func(refs openapi3.SchemaRefs) openapi3.SchemaRefs {
+		if refs == nil {
+			return nil
+		}
+		copied := make(openapi3.SchemaRefs, len(refs))
+		for i, ref := range refs {
+			copied[i] = schemaVariant(ref, role, separated, omit)
+		}
+		return copied
+	}
+	variant.AllOf = copyRefs(variant.AllOf)
+	variant.AnyOf = copyRefs(variant.AnyOf)
+	variant.OneOf = copyRefs(variant.OneOf)
+	if variant.Not != nil {
+		variant.Not = schemaVariant(variant.Not, role, separated, omit)
+	}
+	if variant.Items != nil {
+		variant.Items = schemaVariant(variant.Items, role, separated, omit)
+	}
+	if variant.AdditionalProperties.Schema != nil {
+		variant.AdditionalProperties.Schema = schemaVariant(variant.AdditionalProperties.Schema, role, separated, omit)
+	}
+	if variant.Properties != nil {
+		variant.Properties = openapi3.Schemas{}
+		for name, prop := range ref.Value.Properties {
+			if omit && prop.Value != nil && role.omits(prop.Value) {
+				continue
+			}
+			variant.Properties[name] = schemaVariant(prop, role, separated, omit)
+		}
+		variant.Required = slices.DeleteFunc(slices.Clone(variant.Required), func(name string) bool {
+			_, ok := variant.Properties[name]
+			return !ok
+		})
+	}
+	if variant.Extensions != nil {
+		variant.Extensions = maps.Clone(variant.Extensions)
+	}
+	return openapi3.NewSchemaRef("", &variant)
+}
+
+// subSchemas returns the direct sub schemas of `s`
+func subSchemas(s *openapi3.Schema) openapi3.SchemaRefs {
+	var refs openapi3.SchemaRefs
+	refs = append(refs, s.AllOf...)
+	refs = append(refs, s.AnyOf...)
+	refs = append(refs, s.OneOf...)
+	for _, ref := range []*openapi3.SchemaRef{s.Not, s.Items, s.AdditionalProperties.Schema} {
+		if ref != nil {
+			refs = append(refs, ref)
+		}
+	}
+	for _, prop := range s.Properties {
+		refs = append(refs, prop)
+	}
+	return refs
+}
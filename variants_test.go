@@ -0,0 +1,109 @@
+package expose
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+type variantAccount struct {
+	ID       string `json:"id" openapi:"readOnly"`
+	Name     string `json:"name"`
+	Password string `json:"password" openapi:"writeOnly"`
+}
+
+type variantTeam struct {
+	Name    string           `json:"name"`
+	Members []variantAccount `json:"members"`
+}
+
+type variantInvite struct {
+	Team variantTeam `json:"team"`
+}
+
+func TestSeparateRequestResponseSchemas(t *testing.T) {
+	fns := []Function{
+		Func("/accounts/save", func(ctx context.Context, a variantAccount) (variantAccount, error) {
+			a.ID = "1"
+			return a, nil
+		}, Validate(true)),
+		Func("/teams/save", func(ctx context.Context, team variantTeam) (variantTeam, error) {
+			return team, nil
+		}),
+		FuncVoid("/teams/invite", func(ctx context.Context, invite variantInvite) error {
+			return nil
+		}),
+		FuncNullary("/names/get", func(ctx context.Context) (namedAddress, error) {
+			return namedAddress{}, nil
+		}),
+	}
+
+	g := got.T(t)
+	spec, err := ReflectSpec(openapi3.T{}, fns, SeparateRequestResponseSchemas())
+	g.Must().Nil(err)
+	schemas := spec.Components.Schemas
+	id := "github.com.pbedat.expose.variantAccount"
+
+	t.Run("variants", func(t *testing.T) {
+		g := got.T(t)
+		g.Nil(schemas[id])
+
+		req := schemas[id+"Request"].Value
+		g.Nil(req.Properties["id"])
+		g.NotNil(req.Properties["password"])
+		g.Eq(req.Required, []string{"name", "password"})
+
+		res := schemas[id+"Response"].Value
+		g.NotNil(res.Properties["id"])
+		g.Nil(res.Properties["password"])
+		g.Eq(res.Required, []string{"id", "name"})
+
+		op := spec.Paths.Find("/accounts/save").Post
+		g.Eq(op.RequestBody.Value.Content.Get("application/json").Schema.Ref, "#/components/schemas/"+id+"Request")
+		g.Eq(op.Responses.Status(200).Value.Content.Get("application/json").Schema.Ref, "#/components/schemas/"+id+"Response")
+	})
+
+	t.Run("sub schemas", func(t *testing.T) {
+		g := got.T(t)
+		teamID := "github.com.pbedat.expose.variantTeam"
+		g.Nil(schemas[teamID])
+		g.Eq(schemas[teamID+"Request"].Value.Properties["members"].Value.Items.Ref, "#/components/schemas/"+id+"Request")
+		g.Eq(schemas[teamID+"Response"].Value.Properties["members"].Value.Items.Ref, "#/components/schemas/"+id+"Response")
+
+		// the invite is only a request, it keeps its id
+		invite := schemas["github.com.pbedat.expose.variantInvite"].Value
+		g.Eq(invite.Properties["team"].Ref, "#/components/schemas/"+teamID+"Request")
+	})
+
+	t.Run("schemas without access modes are shared", func(t *testing.T) {
+		g := got.T(t)
+		g.NotNil(schemas["github.com.pbedat.expose.namedAddress"])
+		g.Nil(schemas["github.com.pbedat.expose.namedAddressResponse"])
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		g := got.T(t)
+		spec, err := ReflectSpec(openapi3.T{}, fns)
+		g.Must().Nil(err)
+		g.NotNil(spec.Components.Schemas[id])
+		g.Nil(spec.Components.Schemas[id+"Request"])
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithReflection(SeparateRequestResponseSchemas()), WithResponseValidation(true))
+		g.Must().Nil(err)
+
+		r := httptest.NewRequest(http.MethodPost, "/accounts/save", strings.NewReader(`{"name":"ada","password":"secret"}`))
+		r.Header.Set("content-type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		g.Eq(w.Code, http.StatusOK)
+		g.Has(w.Body.String(), `"id":"1"`)
+	})
+}
@@ -0,0 +1,73 @@
+package expose
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// webhookExtension is the key of the webhooks in an openapi 3.0 spec, which has no webhooks section.
+// It is the extension understood by e.g. Redoc.
+const webhookExtension = "x-webhooks"
+
+// webhook is an outbound request of the service, that is documented with [WithWebhook]
+type webhook struct {
+	name string
+	req  any
+}
+
+// WithWebhook documents a webhook `name`, that the service calls with the payload `req`.
+// The payload is reflected like the requests of the exposed functions, so its schema lands in components/schemas.
+// Webhooks are only documented, they are not served.
+//
+// The webhooks are listed in the `x-webhooks` extension, since openapi 3.0 has no webhooks section.
+// Specs with the version 3.1 (see [WithOpenAPIVersion]) list them in the `webhooks` section.
+func WithWebhook(name string, req any) reflectSpecOpt {
+	return func(s *reflectSettings) {
+		s.webhooks = append(s.webhooks, webhook{name, req})
+	}
+}
+
+// reflectWebhooks adds the webhooks of the settings to the spec `root`
+func (settings reflectSettings) reflectWebhooks(root *openapi3.T, schemas openapi3.Schemas) error {
+	if len(settings.webhooks) == 0 {
+		return nil
+	}
+
+	key := webhookExtension
+	if settings.openAPI31() {
+		key = "webhooks"
+	}
+
+	webhooks := map[string]*openapi3.PathItem{}
+	if existing, ok := root.Extensions[key].(map[string]*openapi3.PathItem); ok {
+		maps.Copy(webhooks, existing)
+	}
+
+	for _, hook := range settings.webhooks {
+		schemaRef, err := reflectSchema(hook.req, schemas, settings)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", hook.name, err)
+		}
+
+		op := openapi3.NewOperation()
+		op.OperationID = hook.name
+		op.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(schemaRef),
+		}
+		op.AddResponse(200, openapi3.NewResponse().WithDescription("The webhook was received"))
+
+		webhooks[hook.name] = &openapi3.PathItem{Post: op}
+	}
+
+	// copy the extensions, so that the provided spec is not mutated
+	extensions := maps.Clone(root.Extensions)
+	if extensions == nil {
+		extensions = map[string]any{}
+	}
+	extensions[key] = webhooks
+	root.Extensions = extensions
+
+	return nil
+}
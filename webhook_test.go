@@ -0,0 +1,66 @@
+package expose
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ysmood/got"
+)
+
+type OrderShipped struct {
+	OrderID string `json:"orderId"`
+	Carrier string `json:"carrier"`
+}
+
+func TestWebhook(t *testing.T) {
+	g := got.T(t)
+
+	fns := []Function{
+		Func("/orders/ship", func(ctx context.Context, id string) (Void, error) {
+			return Void{}, nil
+		}),
+	}
+
+	spec, err := ReflectSpec(openapi3.T{Info: &openapi3.Info{Title: "test"}}, fns, WithWebhook("orderShipped", OrderShipped{}))
+	g.Must().Nil(err)
+
+	g.NotNil(spec.Components.Schemas["github.com.pbedat.expose.OrderShipped"])
+
+	data, err := json.Marshal(&spec)
+	g.Must().Nil(err)
+
+	var doc struct {
+		Webhooks map[string]struct {
+			Post struct {
+				OperationID string `json:"operationId"`
+				RequestBody struct {
+					Required bool `json:"required"`
+					Content  map[string]struct {
+						Schema struct {
+							Ref string `json:"$ref"`
+						} `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+			} `json:"post"`
+		} `json:"x-webhooks"`
+	}
+	g.Must().Nil(json.Unmarshal(data, &doc))
+
+	hook := doc.Webhooks["orderShipped"].Post
+	g.Eq(hook.OperationID, "orderShipped")
+	g.True(hook.RequestBody.Required)
+	g.Eq(hook.RequestBody.Content["application/json"].Schema.Ref, "#/components/schemas/github.com.pbedat.expose.OrderShipped")
+
+	t.Run("not served", func(t *testing.T) {
+		g := got.T(t)
+		h, err := NewHandler(fns, WithReflection(WithWebhook("orderShipped", OrderShipped{})))
+		g.Must().Nil(err)
+
+		spec, err := h.Spec()
+		g.Must().Nil(err)
+		g.NotNil(spec.Extensions[webhookExtension])
+		g.Nil(spec.Paths.Find("orderShipped"))
+	})
+}